@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/n0ko/messages-tui/internal/config"
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// keyEnv is the environment variable the session key can be given in
+const keyEnv = "MESSAGES_TUI_KEY"
+
+// passphraseAttempts is how many times a wrong passphrase can be typed
+const passphraseAttempts = 3
+
+// configuredSecret returns the session key from the environment or a key
+// file, or nil if neither is given
+func configuredSecret(keyFile string) ([]byte, error) {
+	if key := os.Getenv(keyEnv); key != "" {
+		return []byte(key), nil
+	}
+	if keyFile != "" {
+		return readKeyFile(keyFile)
+	}
+	return nil, nil
+}
+
+// readKeyFile reads a session key from a file, ignoring a trailing newline
+func readKeyFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %s can be read by other users, chmod 600 it\n", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	key := bytes.TrimRight(data, "\r\n")
+	if len(key) == 0 {
+		return nil, fmt.Errorf("key file %s is empty", path)
+	}
+	return key, nil
+}
+
+// promptPassphrase asks for a passphrase on the terminal without echoing it
+func promptPassphrase(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return passphrase, nil
+}
+
+// choosePassphrase asks for a new passphrase, twice to catch typos. An empty
+// passphrase is returned as nil if allowEmpty is set.
+func choosePassphrase(prompt string, allowEmpty bool) ([]byte, error) {
+	for {
+		passphrase, err := promptPassphrase(prompt)
+		if err != nil {
+			return nil, err
+		}
+		if len(passphrase) == 0 {
+			if allowEmpty {
+				return nil, nil
+			}
+			fmt.Fprintln(os.Stderr, "The passphrase can't be empty")
+			continue
+		}
+		again, err := promptPassphrase("Repeat it: ")
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, again) {
+			fmt.Fprintln(os.Stderr, "The passphrases don't match, try again")
+			continue
+		}
+		return passphrase, nil
+	}
+}
+
+// unlockSession gives the store the key the session is encrypted with,
+// from the environment, a key file or a passphrase typed in, and opens the
+// session with it. A session saved in the clear gets encrypted, unless
+// plaintext is set or there is neither a key nor a terminal to ask for one.
+// The prompts name the profile if one is given.
+func unlockSession(st *store.Store, keyFile string, profile string, plaintext bool) error {
+	secret, err := configuredSecret(keyFile)
+	if err != nil {
+		return err
+	}
+	if secret != nil {
+		st.SetSecret(secret)
+		_, err := st.LoadSession()
+		return err
+	}
+
+	exists, encrypted, err := st.SavedSession()
+	if err != nil {
+		return err
+	}
+	interactive := term.IsTerminal(os.Stdin.Fd())
+	if !encrypted && (plaintext || !interactive) {
+		if !plaintext {
+			fmt.Fprintf(os.Stderr, "Warning: the session is not encrypted, set %s or use -key-file to encrypt it\n", keyEnv)
+		}
+		_, err := st.LoadSession()
+		return err
+	}
+	if !interactive {
+		return fmt.Errorf("%w: set %s or use -key-file", store.ErrLocked, keyEnv)
+	}
+
+	prompt := "Passphrase: "
+	if profile != "" {
+		fmt.Fprintf(os.Stderr, "Profile %s\n", profile)
+		prompt = fmt.Sprintf("Passphrase for %s: ", profile)
+	}
+	if !encrypted {
+		if exists {
+			fmt.Fprintln(os.Stderr, "Your session is saved unencrypted. Choose a passphrase to encrypt it with;")
+			fmt.Fprintln(os.Stderr, "you will be asked for it every time messages-tui starts.")
+		} else {
+			fmt.Fprintln(os.Stderr, "Choose a passphrase to encrypt your session with; you will be asked")
+			fmt.Fprintln(os.Stderr, "for it every time messages-tui starts.")
+		}
+		fmt.Fprintln(os.Stderr, "Leave it empty to keep the session unencrypted, -no-encrypt stops asking.")
+		passphrase, err := choosePassphrase(prompt, true)
+		if err != nil {
+			return err
+		}
+		if passphrase != nil {
+			st.SetSecret(passphrase)
+		}
+		_, err = st.LoadSession()
+		return err
+	}
+
+	for attempt := 1; attempt <= passphraseAttempts; attempt++ {
+		passphrase, err := promptPassphrase(prompt)
+		if err != nil {
+			return err
+		}
+		st.SetSecret(passphrase)
+		_, err = st.LoadSession()
+		if !errors.Is(err, store.ErrWrongKey) {
+			return err
+		}
+		if attempt < passphraseAttempts {
+			fmt.Fprintln(os.Stderr, "Wrong passphrase, try again")
+		}
+	}
+	return store.ErrWrongKey
+}
+
+// runRekey encrypts the session with a new passphrase or key file, along with
+// the files of the profile used together with others
+func runRekey(st *store.Store, keyFile string, args []string) error {
+	flags := flag.NewFlagSet("rekey", flag.ContinueOnError)
+	newKeyFile := flags.String("new-key-file", "", "Encrypt with the key in this file instead of a passphrase")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	exists, _, err := st.SavedSession()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("no session to rekey, run messages-tui to pair first")
+	}
+
+	fmt.Fprintln(os.Stderr, "Unlocking the current session.")
+	// A session saved in the clear is encrypted with the new secret below
+	if err := unlockSession(st, keyFile, "", true); err != nil {
+		return err
+	}
+
+	combined, err := combinedProfiles(config.Profile())
+	if err != nil {
+		return err
+	}
+	shared := make([]*store.Store, len(combined))
+	for i, name := range combined {
+		shared[i] = store.NewForProfile(name)
+		shared[i].ShareSecret(st)
+	}
+
+	var secret []byte
+	if *newKeyFile != "" {
+		secret, err = readKeyFile(*newKeyFile)
+	} else {
+		secret, err = choosePassphrase("New passphrase: ", false)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := st.Rekey(secret); err != nil {
+		return fmt.Errorf("failed to re-encrypt session: %w", err)
+	}
+	for i, sub := range shared {
+		if err := sub.Rekey(secret); err != nil {
+			return fmt.Errorf("failed to re-encrypt the files of profiles %s: %w", combined[i], err)
+		}
+	}
+	if *newKeyFile != "" {
+		fmt.Printf("Session re-encrypted. Start messages-tui with -key-file %s from now on.\n", *newKeyFile)
+	} else {
+		fmt.Println("Session re-encrypted. Use the new passphrase from now on.")
+	}
+	return nil
+}
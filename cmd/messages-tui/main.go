@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -23,6 +26,12 @@ func main() {
 	// Define flags
 	clearSession := flag.Bool("clear-session", false, "Clear saved session and re-pair with phone")
 	showVersion := flag.Bool("version", false, "Show version information")
+	backend := flag.String("backend", "google", "Messaging backend: google or fake")
+	pair := flag.String("pair", "qr", "How to pair a new device: qr or google")
+	cookiesPath := flag.String("cookies", "", "Browser cookies file for -pair=google (default ~/.config/messages-tui/cookies.json)")
+	keyFile := flag.String("key-file", "", "File holding the key the session is encrypted with, instead of a passphrase")
+	noEncrypt := flag.Bool("no-encrypt", false, "Keep an unencrypted session unencrypted instead of asking for a passphrase")
+	profileFlag := flag.String("profile", config.DefaultProfile, "Profile to use, or several separated by commas to use their phones at once")
 
 	// Custom usage message
 	flag.Usage = func() {
@@ -30,12 +39,27 @@ func main() {
 
 Usage:
   messages-tui [flags]
+  messages-tui [flags] rekey [-new-key-file FILE]
 
 Flags:
+  -profile NAME     Use the session, caches and config overrides of a profile;
+                    several names separated by commas use their phones at once
   -clear-session    Clear saved session and re-pair with phone
+  -backend NAME     Messaging backend: google (default) or fake (offline demo data)
+  -pair METHOD      Pair a new device by qr (default) or google account, see below
+  -cookies FILE     Browser cookies file for -pair=google
+                    (default cookies.json in the profile's directory)
+  -key-file FILE    Encrypt the session and saved files with the key in FILE
+                    instead of asking for a passphrase; %s works too
+  -no-encrypt       Keep the session unencrypted and don't ask for a
+                    passphrase to encrypt it with
   -version          Show version information
   -h, -help         Show this help message
 
+Commands:
+  rekey             Encrypt the session and saved files with a new
+                    passphrase, or with the key in -new-key-file
+
 Key Bindings:
   j/k or ↑/↓        Navigate messages/contacts
   Tab               Switch panels
@@ -49,6 +73,8 @@ File Locations:
   Config:   ~/.config/messages-tui/config.yaml
   Session:  ~/.config/messages-tui/session.json
   Logs:     ~/.config/messages-tui/messages-tui.log
+  Profiles: ~/.config/messages-tui/profiles/NAME/, holding the same files;
+            a profile's config.yaml overrides the settings it sets
 
 First Launch:
   1. Run messages-tui
@@ -56,7 +82,13 @@ First Launch:
      (Menu ⋮ → Device Pairing → QR Scanner)
   3. Your conversations will sync automatically
 
-`)
+Pairing Without a QR Code:
+  1. Sign in to messages.google.com in a browser with the phone's Google account
+  2. Export its cookies for google.com to a file (JSON or cookies.txt)
+  3. Run messages-tui -pair=google -cookies FILE
+  4. Tap the emoji shown in the terminal on your phone
+
+`, keyEnv)
 	}
 
 	flag.Parse()
@@ -67,17 +99,43 @@ First Launch:
 		os.Exit(0)
 	}
 
+	profiles, err := parseProfiles(*profileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	// Several profiles at once keep their pins, outbox and logs apart from
+	// each profile's own
+	config.SetProfile(strings.Join(profiles, "+"))
+
 	// Handle clear-session flag
 	if *clearSession {
-		st := store.New()
-		if err := st.ClearSession(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error clearing session: %v\n", err)
-			os.Exit(1)
+		for _, name := range profiles {
+			if err := store.NewForProfile(name).ClearSession(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error clearing session of profile %s: %v\n", name, err)
+				os.Exit(1)
+			}
 		}
 		fmt.Println("Session cleared. Run messages-tui to pair again.")
 		os.Exit(0)
 	}
 
+	if flag.Arg(0) == "rekey" {
+		if len(profiles) > 1 {
+			fmt.Fprintf(os.Stderr, "Rekey one profile at a time\n")
+			os.Exit(2)
+		}
+		if err := runRekey(store.New(), *keyFile, flag.Args()[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if flag.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", flag.Arg(0))
+		os.Exit(2)
+	}
+
 	// Set up logging
 	logFile, err := setupLogging()
 	if err != nil {
@@ -92,14 +150,127 @@ First Launch:
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Create store
+	// Create store. With several profiles each phone keeps its session and
+	// caches in its own store, mirrored into st.
 	st := store.New()
+	stores := []*store.Store{st}
+	if len(profiles) > 1 {
+		stores = make([]*store.Store, len(profiles))
+		for i, name := range profiles {
+			stores[i] = store.NewForProfile(name)
+		}
+	}
+
+	// The saved files are encrypted, get their key before reading them and
+	// before the UI takes over. Only the google backend needs a session, so
+	// the others don't ask to encrypt one.
+	for i, sub := range stores {
+		label := ""
+		if len(stores) > 1 {
+			label = profiles[i]
+		}
+		if err := unlockSession(sub, *keyFile, label, *noEncrypt || *backend != "google"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening session: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(stores) > 1 {
+		// The outbox and settings of several profiles at once go with the
+		// first one's key
+		st.ShareSecret(stores[0])
+	}
+
+	if err := st.LoadOutbox(); err != nil {
+		loadFailed("outbox", err)
+	}
+	if err := st.LoadSchedule(); err != nil {
+		loadFailed("scheduled messages", err)
+	}
+	if err := st.LoadContacts(); err != nil {
+		loadFailed("contacts", err)
+	}
+	if err := st.LoadConversationPrefs(); err != nil {
+		loadFailed("conversation settings", err)
+	}
+	if len(stores) > 1 {
+		for i, sub := range stores {
+			if err := sub.LoadContacts(); err != nil {
+				loadFailed("contacts of profile "+profiles[i], err)
+			}
+		}
+	}
+
+	// Google account pairing needs cookies, fail before the UI takes over
+	cookies := make([]map[string]string, len(stores))
+	switch *pair {
+	case "qr":
+	case "google":
+		if *backend != "google" {
+			fmt.Fprintf(os.Stderr, "-pair=google only works with the google backend\n")
+			os.Exit(2)
+		}
+		if *cookiesPath != "" && len(profiles) > 1 {
+			fmt.Fprintf(os.Stderr, "-cookies only works with one profile, save each profile's cookies.json in its directory instead\n")
+			os.Exit(2)
+		}
+		for i, name := range profiles {
+			path := *cookiesPath
+			if path == "" {
+				dir, err := config.ProfileDir(name)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error finding cookies: %v\n", err)
+					os.Exit(1)
+				}
+				path = filepath.Join(dir, "cookies.json")
+			}
+			cookies[i], err = client.LoadCookies(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading cookies: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown pairing method %q (expected qr or google)\n", *pair)
+		os.Exit(2)
+	}
 
-	// Create client
-	cl := client.New(st)
+	// Create messaging backends, one per profile
+	backends := make([]client.Messenger, len(stores))
+	googles := make([]*client.Client, len(stores))
+	for i, sub := range stores {
+		switch *backend {
+		case "google":
+			googles[i] = client.New(sub)
+			backends[i] = googles[i]
+		case "fake":
+			backends[i] = client.NewFake(sub, client.DefaultFakeScript())
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown backend %q (expected google or fake)\n", *backend)
+			os.Exit(2)
+		}
+	}
+	cl := backends[0]
+	var accounts *client.Accounts
+	if len(profiles) > 1 {
+		list := make([]*client.Account, len(profiles))
+		for i, name := range profiles {
+			list[i] = &client.Account{Name: name, Store: stores[i], Messenger: backends[i]}
+		}
+		accounts = client.NewAccounts(st, list)
+		cl = accounts
+	}
 
 	// Create application
 	app := ui.NewApp(cfg, st, cl)
+	for i := range cookies {
+		if cookies[i] != nil {
+			googles[i].UseGoogleLogin(cookies[i], app.SetPairingEmoji)
+		}
+	}
+	if accounts != nil {
+		app.SetAccounts(accounts.Names())
+		accounts.OnStart(app.SetPairingAccount)
+	}
 
 	// Set up context for cancellation
 	ctx, cancel := context.WithCancel(context.Background())
@@ -115,9 +286,11 @@ First Launch:
 
 	// Try to restore session or start pairing
 	go func() {
-		if err := initializeClient(ctx, st, cl, app); err != nil {
+		if err := cl.Start(ctx, app.SetQRCode); err != nil {
 			app.SetError(err)
+			return
 		}
+		app.SetConnected()
 	}()
 
 	// Create and run the Bubble Tea program
@@ -127,11 +300,36 @@ First Launch:
 		tea.WithMouseCellMotion(),
 	)
 
-	if _, err := p.Run(); err != nil {
+	_, err = p.Run()
+	// Closing saves the latest credentials of every phone. Events sent while
+	// the backends shut down have nobody to go to anymore.
+	go func() {
+		for range cl.EventChannel() {
+		}
+	}()
+	cl.Close()
+	// Decrypted attachments don't outlive the app
+	for _, sub := range append(stores, st) {
+		if err := sub.Close(); err != nil {
+			log.Printf("Failed to remove decrypted attachments: %v", err)
+		}
+	}
+	if err != nil {
 		log.Fatalf("Error running program: %v", err)
 	}
 }
 
+// loadFailed reports a saved file that couldn't be loaded. One that can't be
+// decrypted stops the app, as saving would overwrite it.
+func loadFailed(what string, err error) {
+	if errors.Is(err, store.ErrWrongKey) || errors.Is(err, store.ErrLocked) {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", what, err)
+		fmt.Fprintf(os.Stderr, "It is encrypted with another key. Start with that key, or move the file aside to start over.\n")
+		os.Exit(1)
+	}
+	log.Printf("Failed to load %s: %v", what, err)
+}
+
 // setupLogging sets up logging to a file
 func setupLogging() (*os.File, error) {
 	dir, err := config.ConfigDir()
@@ -154,57 +352,3 @@ func setupLogging() (*os.File, error) {
 
 	return f, nil
 }
-
-// initializeClient initializes the client connection
-func initializeClient(ctx context.Context, st *store.Store, cl *client.Client, app *ui.App) error {
-	// Create auth handler
-	auth := client.NewAuthHandler(st)
-	defer auth.Close()
-
-	// Try to restore existing session
-	gmClient, err := auth.RestoreSession(ctx)
-	if err != nil {
-		log.Printf("Failed to restore session: %v", err)
-	}
-
-	if gmClient != nil {
-		// Session restored successfully
-		log.Println("Session restored")
-		cl.SetClient(gmClient)
-		app.SetConnected()
-		return nil
-	}
-
-	// Need to pair via QR code
-	log.Println("Starting QR pairing...")
-	gmClient, err = auth.StartPairing(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to start pairing: %w", err)
-	}
-
-	// Wait for QR code or completion
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-
-		case qr := <-auth.QRChannel():
-			log.Println("QR code received")
-			app.SetQRCode(qr.URL)
-
-		case err := <-auth.ErrorChannel():
-			return err
-
-		case <-auth.DoneChannel():
-			log.Println("Pairing completed, connecting client...")
-			// After pairing, we need to explicitly connect for messaging
-			if err := gmClient.Connect(); err != nil {
-				return fmt.Errorf("failed to connect after pairing: %w", err)
-			}
-			log.Println("Client connected successfully")
-			cl.SetClient(gmClient)
-			app.SetConnected()
-			return nil
-		}
-	}
-}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/n0ko/messages-tui/internal/config"
+)
+
+// parseProfiles splits the -profile flag into profile names, keeping the
+// order they were given in
+func parseProfiles(value string) ([]string, error) {
+	var profiles []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if err := config.ValidateProfile(name); err != nil {
+			return nil, err
+		}
+		if slices.Contains(profiles, name) {
+			return nil, fmt.Errorf("profile %s is given twice", name)
+		}
+		profiles = append(profiles, name)
+	}
+	return profiles, nil
+}
+
+// combinedProfiles returns the names several profiles used at once go by that
+// start with profile. Their outbox and settings are encrypted with its key.
+func combinedProfiles(profile string) ([]string, error) {
+	base, err := config.BaseDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(base, "profiles"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), profile+"+") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
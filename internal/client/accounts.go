@@ -0,0 +1,605 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// accountSeparator joins an account name to an ID from its phone. Profile
+// names can't contain it.
+const accountSeparator = ":"
+
+// Account is one of several phones used at once
+type Account struct {
+	// Name is the profile the phone is paired in
+	Name string
+	// Store holds the account's session and caches
+	Store *store.Store
+	// Messenger talks to the account's phone
+	Messenger Messenger
+}
+
+// accountKey is the context key of the account set with WithAccount
+type accountKey struct{}
+
+// WithAccount returns a context that makes Accounts create conversations and
+// list contacts on the named account
+func WithAccount(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, accountKey{}, name)
+}
+
+// Accounts runs several accounts as one Messenger. Each account keeps its
+// own store; Accounts mirrors them into the store the UI reads, with the
+// conversation and SIM IDs of each phone qualified by the account name so
+// they can't collide.
+type Accounts struct {
+	store     *store.Store
+	accounts  []*Account
+	eventChan chan Event
+	onStart   func(name string)
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewAccounts creates a backend running the given accounts, in the order
+// they are listed in
+func NewAccounts(st *store.Store, accounts []*Account) *Accounts {
+	return &Accounts{
+		store:     st,
+		accounts:  accounts,
+		eventChan: make(chan Event, 100),
+		done:      make(chan struct{}),
+	}
+}
+
+// OnStart sets a function called with the name of each account before it
+// is started, so pairing screens can say which phone they are for
+func (m *Accounts) OnStart(fn func(name string)) {
+	m.onStart = fn
+}
+
+// Names returns the names of the accounts, in order
+func (m *Accounts) Names() []string {
+	names := make([]string, len(m.accounts))
+	for i, acct := range m.accounts {
+		names[i] = acct.Name
+	}
+	return names
+}
+
+// qualify prefixes an ID from an account's phone with the account name
+func qualify(account, id string) string {
+	if id == "" {
+		return ""
+	}
+	return account + accountSeparator + id
+}
+
+// unqualify strips the account name from an ID made by qualify
+func unqualify(id string) string {
+	_, local, found := strings.Cut(id, accountSeparator)
+	if !found {
+		return id
+	}
+	return local
+}
+
+// AccountOf returns the account a conversation ID qualified by Accounts
+// belongs to, or "" for an ID from a backend running a single account
+func AccountOf(conversationID string) string {
+	name, _, found := strings.Cut(conversationID, accountSeparator)
+	if !found {
+		return ""
+	}
+	return name
+}
+
+// account returns the account named name
+func (m *Accounts) account(name string) (*Account, error) {
+	for _, acct := range m.accounts {
+		if acct.Name == name {
+			return acct, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown account %q", name)
+}
+
+// resolve returns the account a qualified conversation ID belongs to and the
+// ID its phone knows the conversation by
+func (m *Accounts) resolve(conversationID string) (*Account, string, error) {
+	name, id, found := strings.Cut(conversationID, accountSeparator)
+	if !found {
+		return nil, "", fmt.Errorf("conversation %s has no account", conversationID)
+	}
+	acct, err := m.account(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return acct, id, nil
+}
+
+// contextAccount returns the account set on ctx with WithAccount, or the
+// first one
+func (m *Accounts) contextAccount(ctx context.Context) (*Account, error) {
+	if name, ok := ctx.Value(accountKey{}).(string); ok && name != "" {
+		return m.account(name)
+	}
+	return m.accounts[0], nil
+}
+
+// qualifyConversation returns a copy of an account's conversation as the UI
+// sees it
+func qualifyConversation(account string, conv *store.Conversation) *store.Conversation {
+	c := *conv
+	c.ID = qualify(account, conv.ID)
+	c.OutgoingID = qualify(account, conv.OutgoingID)
+	c.Participants = slices.Clone(conv.Participants)
+	c.Account = account
+	return &c
+}
+
+// qualifyMessage returns a copy of an account's message as the UI sees it
+func qualifyMessage(account string, msg *store.Message) *store.Message {
+	m := *msg
+	m.ConversationID = qualify(account, msg.ConversationID)
+	return &m
+}
+
+// qualifyMessages returns copies of an account's messages as the UI sees them
+func qualifyMessages(account string, msgs []*store.Message) []*store.Message {
+	qualified := make([]*store.Message, len(msgs))
+	for i, msg := range msgs {
+		qualified[i] = qualifyMessage(account, msg)
+	}
+	return qualified
+}
+
+// mirrorConversations copies conversations of an account into the UI's
+// store and returns the copies
+func (m *Accounts) mirrorConversations(acct *Account, convs []*store.Conversation) []*store.Conversation {
+	mirrored := make([]*store.Conversation, len(convs))
+	for i, conv := range convs {
+		mirrored[i] = qualifyConversation(acct.Name, conv)
+	}
+	m.store.SetConversations(mirrored)
+	return mirrored
+}
+
+// mirrorConversation copies one conversation of an account into the UI's store
+func (m *Accounts) mirrorConversation(acct *Account, conversationID string) {
+	if conv := acct.Store.GetConversation(conversationID); conv != nil {
+		m.mirrorConversations(acct, []*store.Conversation{conv})
+	}
+}
+
+// mirrorMessage copies one message of an account into the UI's store
+func (m *Accounts) mirrorMessage(acct *Account, conversationID, messageID string) {
+	for _, msg := range acct.Store.GetMessages(conversationID) {
+		if msg.ID == messageID {
+			m.store.AddMessage(qualifyMessage(acct.Name, msg))
+			return
+		}
+	}
+}
+
+// mirrorContacts replaces the UI's contact list with every account's
+func (m *Accounts) mirrorContacts() {
+	var contacts []*store.Contact
+	for _, acct := range m.accounts {
+		contacts = append(contacts, acct.Store.GetContacts()...)
+	}
+	if err := m.store.SetContacts(contacts); err != nil {
+		log.Printf("Accounts: failed to save contacts: %v", err)
+	}
+}
+
+// mirrorSIMs replaces the UI's SIM list with every account's
+func (m *Accounts) mirrorSIMs() {
+	var sims []store.SIM
+	for _, acct := range m.accounts {
+		for _, sim := range acct.Store.GetSIMs() {
+			sim.ParticipantID = qualify(acct.Name, sim.ParticipantID)
+			sim.Account = acct.Name
+			sims = append(sims, sim)
+		}
+	}
+	m.store.SetSIMs(sims)
+}
+
+// forward passes an account's events on once the UI's store has caught up
+// with them, until the account is closed
+func (m *Accounts) forward(acct *Account) {
+	defer m.wg.Done()
+	for evt := range acct.Messenger.EventChannel() {
+		evt = m.translate(acct, evt)
+		select {
+		case m.eventChan <- evt:
+		case <-m.done:
+			// Nobody listens anymore, keep draining so the account can close
+		}
+	}
+}
+
+// translate mirrors what an event changed into the UI's store and returns
+// the event as the UI sees it
+func (m *Accounts) translate(acct *Account, evt Event) Event {
+	if evt.Error != nil {
+		evt.Error = fmt.Errorf("%s: %w", acct.Name, evt.Error)
+	}
+	if evt.Conversation != nil {
+		evt.Conversation = qualifyConversation(acct.Name, evt.Conversation)
+	}
+
+	switch evt.Type {
+	case EventTypeNewMessage, EventTypeMessageUpdated:
+		if evt.Message == nil {
+			break
+		}
+		evt.Message = qualifyMessage(acct.Name, evt.Message)
+		// The UI's store decides, it holds the local echoes
+		evt.Type = EventTypeMessageUpdated
+		if m.store.AddMessage(evt.Message) {
+			evt.Type = EventTypeNewMessage
+		}
+
+	case EventTypeMessageDeleted:
+		if evt.Message == nil {
+			break
+		}
+		evt.Message = qualifyMessage(acct.Name, evt.Message)
+		m.store.RemoveMessage(evt.Message.ConversationID, evt.Message.ID)
+
+	case EventTypeConversationsUpdated:
+		m.mirrorConversations(acct, acct.Store.GetConversations())
+
+	case EventTypeContactsUpdated:
+		m.mirrorContacts()
+
+	case EventTypeSIMsUpdated:
+		m.mirrorSIMs()
+
+	case EventTypePhoneStatus:
+		if status, ok := evt.Data.(*PhoneStatus); ok {
+			s := *status
+			s.Account = acct.Name
+			evt.Data = &s
+		}
+
+	case EventTypeSessionExpiring:
+		if warning, ok := evt.Data.(*SessionWarning); ok {
+			w := *warning
+			w.Account = acct.Name
+			evt.Data = &w
+		}
+
+	case EventTypeTypingIndicator:
+		if info, ok := evt.Data.(*TypingInfo); ok {
+			i := *info
+			i.ConversationID = qualify(acct.Name, info.ConversationID)
+			evt.Data = &i
+		}
+	}
+	return evt
+}
+
+// Start starts the accounts one after the other, pairing the ones without a
+// session. It fails if any account does.
+func (m *Accounts) Start(ctx context.Context, onQR func(url string)) error {
+	for _, acct := range m.accounts {
+		m.wg.Add(1)
+		go m.forward(acct)
+	}
+
+	for _, acct := range m.accounts {
+		if m.onStart != nil {
+			m.onStart(acct.Name)
+		}
+		log.Printf("Accounts: starting %s", acct.Name)
+		if err := acct.Messenger.Start(ctx, onQR); err != nil {
+			return fmt.Errorf("failed to start account %s: %w", acct.Name, err)
+		}
+	}
+	return nil
+}
+
+// EventChannel returns the channel for receiving the events of every account
+func (m *Accounts) EventChannel() <-chan Event {
+	return m.eventChan
+}
+
+// IsConnected returns whether any account is connected
+func (m *Accounts) IsConnected() bool {
+	for _, acct := range m.accounts {
+		if acct.Messenger.IsConnected() {
+			return true
+		}
+	}
+	return false
+}
+
+// ListConversations refreshes the most recent conversations of every
+// account and returns every conversation loaded so far
+func (m *Accounts) ListConversations(ctx context.Context) ([]*store.Conversation, error) {
+	for _, acct := range m.accounts {
+		convs, err := acct.Messenger.ListConversations(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", acct.Name, err)
+		}
+		m.mirrorConversations(acct, convs)
+	}
+	return m.store.GetConversations(), nil
+}
+
+// ListConversationsPage pages through the conversations of each account in
+// turn. The cursor is the account name and that account's own cursor.
+func (m *Accounts) ListConversationsPage(ctx context.Context, cursor string) (*ConversationPage, error) {
+	acct := m.accounts[0]
+	if cursor != "" {
+		name, rest, _ := strings.Cut(cursor, accountSeparator)
+		var err error
+		if acct, err = m.account(name); err != nil {
+			return nil, err
+		}
+		cursor = rest
+	}
+
+	page, err := acct.Messenger.ListConversationsPage(ctx, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", acct.Name, err)
+	}
+
+	next := ""
+	switch i := slices.Index(m.accounts, acct); {
+	case page.NextCursor != "":
+		next = acct.Name + accountSeparator + page.NextCursor
+	case i+1 < len(m.accounts):
+		// The next account's first page
+		next = m.accounts[i+1].Name + accountSeparator
+	}
+	return &ConversationPage{
+		Conversations: m.mirrorConversations(acct, page.Conversations),
+		NextCursor:    next,
+	}, nil
+}
+
+// GetMessages refreshes the newest messages of a conversation and returns
+// its whole loaded history, oldest first
+func (m *Accounts) GetMessages(ctx context.Context, conversationID string) ([]*store.Message, error) {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := acct.Messenger.GetMessages(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	m.store.MergeMessages(conversationID, qualifyMessages(acct.Name, msgs))
+	return m.store.GetMessages(conversationID), nil
+}
+
+// GetMessagesPage fetches the page of messages older than cursor, or the
+// newest page if cursor is empty
+func (m *Accounts) GetMessagesPage(ctx context.Context, conversationID string, cursor string) (*MessagePage, error) {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	page, err := acct.Messenger.GetMessagesPage(ctx, id, cursor)
+	if err != nil {
+		return nil, err
+	}
+	msgs := qualifyMessages(acct.Name, page.Messages)
+	m.store.MergeMessages(conversationID, msgs)
+	return &MessagePage{Messages: msgs, NextCursor: page.NextCursor}, nil
+}
+
+// ListContacts fetches the contact list of the account set on ctx with
+// WithAccount, or of every account
+func (m *Accounts) ListContacts(ctx context.Context) ([]*store.Contact, error) {
+	if _, ok := ctx.Value(accountKey{}).(string); ok {
+		acct, err := m.contextAccount(ctx)
+		if err != nil {
+			return nil, err
+		}
+		contacts, err := acct.Messenger.ListContacts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.mirrorContacts()
+		return contacts, nil
+	}
+
+	for _, acct := range m.accounts {
+		if _, err := acct.Messenger.ListContacts(ctx); err != nil {
+			return nil, fmt.Errorf("%s: %w", acct.Name, err)
+		}
+	}
+	m.mirrorContacts()
+	return m.store.GetContacts(), nil
+}
+
+// CreateConversation opens the conversation with the given phone numbers on
+// the account set on ctx with WithAccount, or the first one
+func (m *Accounts) CreateConversation(ctx context.Context, numbers []string) (*store.Conversation, error) {
+	acct, err := m.contextAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conv, err := acct.Messenger.CreateConversation(ctx, numbers)
+	if err != nil {
+		return nil, err
+	}
+	m.mirrorConversations(acct, []*store.Conversation{conv})
+	return m.store.GetConversation(qualify(acct.Name, conv.ID)), nil
+}
+
+// sendingSIM returns the SIM a message to a conversation goes out on, as
+// its phone knows it. The account's own store doesn't know which SIM was
+// chosen here.
+func (m *Accounts) sendingSIM(conversationID, simID string) string {
+	if simID == "" {
+		if conv := m.store.GetConversation(conversationID); conv != nil && conv.SIM != "" {
+			simID = conv.SIM
+		}
+	}
+	return unqualify(simID)
+}
+
+// SendMessage sends a text message from the conversation's account
+func (m *Accounts) SendMessage(ctx context.Context, conversationID string, text string, tmpID string, replyToID string, simID string) error {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return err
+	}
+	return acct.Messenger.SendMessage(ctx, id, text, tmpID, replyToID, m.sendingSIM(conversationID, simID))
+}
+
+// SendMedia uploads and sends a file from the conversation's account
+func (m *Accounts) SendMedia(ctx context.Context, conversationID string, path string, caption string, tmpID string, simID string, progress func(UploadProgress)) error {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return err
+	}
+	return acct.Messenger.SendMedia(ctx, id, path, caption, tmpID, m.sendingSIM(conversationID, simID), progress)
+}
+
+// SendReaction reacts to a message from the conversation's account
+func (m *Accounts) SendReaction(ctx context.Context, conversationID string, messageID string, emoji string, action ReactionAction) error {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return err
+	}
+	if err := acct.Messenger.SendReaction(ctx, id, messageID, emoji, action); err != nil {
+		return err
+	}
+	m.mirrorMessage(acct, id, messageID)
+	return nil
+}
+
+// DeleteMessage deletes a message on the conversation's phone
+func (m *Accounts) DeleteMessage(ctx context.Context, conversationID string, messageID string) error {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return err
+	}
+	if err := acct.Messenger.DeleteMessage(ctx, id, messageID); err != nil {
+		return err
+	}
+	m.store.RemoveMessage(conversationID, messageID)
+	return nil
+}
+
+// ArchiveConversation archives or unarchives a conversation on its phone
+func (m *Accounts) ArchiveConversation(ctx context.Context, conversationID string, archived bool) error {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return err
+	}
+	if err := acct.Messenger.ArchiveConversation(ctx, id, archived); err != nil {
+		return err
+	}
+	m.mirrorConversation(acct, id)
+	return nil
+}
+
+// MuteConversation mutes or unmutes a conversation on its phone
+func (m *Accounts) MuteConversation(ctx context.Context, conversationID string, muted bool) error {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return err
+	}
+	return acct.Messenger.MuteConversation(ctx, id, muted)
+}
+
+// BlockConversation blocks a conversation on its phone
+func (m *Accounts) BlockConversation(ctx context.Context, conversationID string, report bool) error {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return err
+	}
+	if err := acct.Messenger.BlockConversation(ctx, id, report); err != nil {
+		return err
+	}
+	m.mirrorConversation(acct, id)
+	return nil
+}
+
+// UnblockConversation unblocks a conversation on its phone
+func (m *Accounts) UnblockConversation(ctx context.Context, conversationID string) error {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return err
+	}
+	if err := acct.Messenger.UnblockConversation(ctx, id); err != nil {
+		return err
+	}
+	m.mirrorConversation(acct, id)
+	return nil
+}
+
+// LoadBlockedConversations fetches the spam & blocked folder of every account
+func (m *Accounts) LoadBlockedConversations(ctx context.Context) error {
+	for _, acct := range m.accounts {
+		if err := acct.Messenger.LoadBlockedConversations(ctx); err != nil {
+			return fmt.Errorf("%s: %w", acct.Name, err)
+		}
+		var blocked []*store.Conversation
+		for _, conv := range acct.Store.GetConversations() {
+			if conv.Blocked {
+				blocked = append(blocked, conv)
+			}
+		}
+		m.mirrorConversations(acct, blocked)
+	}
+	return nil
+}
+
+// DownloadMedia downloads an attachment through the message's account into
+// that account's media cache
+func (m *Accounts) DownloadMedia(ctx context.Context, msg *store.Message) (string, error) {
+	acct, id, err := m.resolve(msg.ConversationID)
+	if err != nil {
+		return "", err
+	}
+	local := *msg
+	local.ConversationID = id
+	return acct.Messenger.DownloadMedia(ctx, &local)
+}
+
+// SetTyping notifies a conversation's phone that the user is typing
+func (m *Accounts) SetTyping(ctx context.Context, conversationID string) error {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return err
+	}
+	return acct.Messenger.SetTyping(ctx, id)
+}
+
+// MarkRead marks a conversation as read on its phone
+func (m *Accounts) MarkRead(ctx context.Context, conversationID string, messageID string) error {
+	acct, id, err := m.resolve(conversationID)
+	if err != nil {
+		return err
+	}
+	if err := acct.Messenger.MarkRead(ctx, id, messageID); err != nil {
+		return err
+	}
+	m.store.MarkConversationRead(conversationID)
+	return nil
+}
+
+// Close closes every account
+func (m *Accounts) Close() {
+	close(m.done)
+	for _, acct := range m.accounts {
+		acct.Messenger.Close()
+	}
+	m.wg.Wait()
+	close(m.eventChan)
+}
@@ -128,7 +128,7 @@ func (a *AuthHandler) handlePairingEvent(evt any) {
 
 		// Marshal the auth data
 		if a.client != nil && a.client.AuthData != nil {
-			if authData, err := json.Marshal(a.client.AuthData); err == nil {
+			if authData, err := marshalAuthData(a.client.AuthData); err == nil {
 				session.DevicePair = authData
 			}
 		}
@@ -210,8 +210,13 @@ func (a *AuthHandler) RestoreSession(ctx context.Context) (*libgm.Client, error)
 		return nil, nil
 	}
 
-	// Update last used time
-	_ = a.store.SaveSession(session)
+	// Connecting refreshes the token if it was close to expiring, keep it
+	if data, err := marshalAuthData(client.AuthData); err == nil {
+		session.DevicePair = data
+	}
+	if err := a.store.SaveSession(session); err != nil {
+		log.Printf("Auth: failed to save session: %v", err)
+	}
 
 	return client, nil
 }
@@ -2,8 +2,12 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 
 	"go.mau.fi/mautrix-gmessages/pkg/libgm"
@@ -13,6 +17,16 @@ import (
 	"github.com/n0ko/messages-tui/internal/store"
 )
 
+// conversationPageSize is the number of conversations fetched for the first page
+const conversationPageSize = 25
+
+// maxConversationWindow is the most conversations ListConversationsPage asks
+// the phone for at once
+const maxConversationWindow = 800
+
+// messagePageSize is the number of messages fetched per page of history
+const messagePageSize = 50
+
 // Client wraps libgm.Client with additional functionality
 type Client struct {
 	mu        sync.RWMutex
@@ -20,14 +34,42 @@ type Client struct {
 	store     *store.Store
 	eventChan chan Event
 	connected bool
+
+	// Reconnect handling
+	supervisor  *supervisor
+	interrupted bool // a temporary listen error is unresolved
+
+	// What picks each of the phone's SIMs, keyed by the user's participant ID
+	// on it
+	simPayloads map[string]*gmproto.SIMPayload
+
+	// What the phone last said about itself
+	phone PhoneStatus
+
+	// Session persistence, see watchSession
+	sessionMu    sync.Mutex
+	savedAuth    []byte // auth data as last written to the session file
+	expiryWarned bool
+	watchOnce    sync.Once
+	done         chan struct{}
+
+	// The archive was listed, see loadArchive
+	archiveLoaded bool
+
+	// Google account pairing instead of QR, see UseGoogleLogin
+	googleCookies map[string]string
+	onEmoji       func(emoji string)
 }
 
 // New creates a new Client instance
 func New(st *store.Store) *Client {
-	return &Client{
+	c := &Client{
 		store:     st,
 		eventChan: make(chan Event, 100),
+		done:      make(chan struct{}),
 	}
+	c.supervisor = newSupervisor(c)
+	return c
 }
 
 // EventChannel returns the channel for receiving events
@@ -44,6 +86,79 @@ func (c *Client) SetClient(client *libgm.Client) {
 	if client != nil {
 		client.SetEventHandler(c.handleEvent)
 		c.connected = true
+		c.watchOnce.Do(func() { go c.watchSession() })
+	}
+}
+
+// Start restores the saved session, or pairs a new device via QR code or
+// the Google account if there is none, and hands the resulting libgm client
+// to c
+func (c *Client) Start(ctx context.Context, onQR func(url string)) error {
+	auth := NewAuthHandler(c.store)
+
+	// Try to restore existing session
+	gmClient, err := auth.RestoreSession(ctx)
+	if errors.Is(err, store.ErrLocked) || errors.Is(err, store.ErrWrongKey) {
+		// Pairing again would overwrite the session that couldn't be opened
+		return err
+	}
+	if err != nil {
+		log.Printf("Failed to restore session: %v", err)
+	}
+
+	if gmClient != nil {
+		// Session restored successfully
+		log.Println("Session restored")
+		c.SetClient(gmClient)
+		return nil
+	}
+
+	c.mu.RLock()
+	cookies, onEmoji := c.googleCookies, c.onEmoji
+	c.mu.RUnlock()
+	if cookies != nil {
+		log.Println("Starting Google account pairing...")
+		gmClient, err = auth.PairGoogle(ctx, cookies, onEmoji)
+		if err != nil {
+			return err
+		}
+		return c.connectPaired(gmClient)
+	}
+
+	// Need to pair via QR code
+	log.Println("Starting QR pairing...")
+	gmClient, err = auth.StartPairing(ctx)
+	if err != nil {
+		auth.Close()
+		return fmt.Errorf("failed to start pairing: %w", err)
+	}
+
+	// Wait for QR code or completion
+	for {
+		select {
+		case <-ctx.Done():
+			auth.Close()
+			return ctx.Err()
+
+		case qr := <-auth.QRChannel():
+			log.Println("QR code received")
+			onQR(qr.URL)
+
+		case err := <-auth.ErrorChannel():
+			auth.Close()
+			return err
+
+		case <-auth.DoneChannel():
+			log.Println("Pairing completed, connecting client...")
+			// After pairing, we need to explicitly connect for messaging
+			if err := gmClient.Connect(); err != nil {
+				auth.Close()
+				return fmt.Errorf("failed to connect after pairing: %w", err)
+			}
+			log.Println("Client connected successfully")
+			c.SetClient(gmClient)
+			return nil
+		}
 	}
 }
 
@@ -73,6 +188,27 @@ func (c *Client) Connect(ctx context.Context) error {
 	return nil
 }
 
+// reconnect tears down and re-establishes the libgm connection
+func (c *Client) reconnect() error {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+
+	if err := client.Reconnect(); err != nil {
+		return fmt.Errorf("failed to reconnect: %w", err)
+	}
+
+	c.mu.Lock()
+	c.connected = true
+	c.interrupted = false
+	c.mu.Unlock()
+	return nil
+}
+
 // Disconnect disconnects from Google Messages
 func (c *Client) Disconnect() {
 	c.mu.Lock()
@@ -92,15 +228,36 @@ func (c *Client) handleEvent(evt any) {
 		c.mu.Lock()
 		c.connected = false
 		c.mu.Unlock()
-		c.eventChan <- Event{
-			Type:  EventTypeError,
-			Error: fmt.Errorf("fatal error: %v", e.Error),
+		if isAuthError(e.Error) {
+			// Retrying with rejected credentials is pointless
+			c.eventChan <- Event{
+				Type:  EventTypeError,
+				Error: fmt.Errorf("%w: %v", ErrSessionExpired, e.Error),
+			}
+			return
 		}
+		c.supervisor.trigger(e.Error)
 
 	case *events.ListenTemporaryError:
+		// libgm keeps polling by itself after temporary errors
+		c.mu.Lock()
+		c.interrupted = true
+		c.mu.Unlock()
 		c.eventChan <- Event{
-			Type:  EventTypeError,
-			Error: fmt.Errorf("temporary error: %v", e.Error),
+			Type:  EventTypeReconnecting,
+			Error: e.Error,
+			Data:  &ReconnectInfo{Temporary: true},
+		}
+
+	case *events.ListenRecovered:
+		c.mu.Lock()
+		wasInterrupted := c.interrupted
+		c.interrupted = false
+		c.connected = true
+		c.mu.Unlock()
+		// libgm also sends this on the first successful poll, only report real recoveries
+		if wasInterrupted {
+			c.eventChan <- Event{Type: EventTypeReconnected}
 		}
 
 	case *events.ClientReady:
@@ -108,28 +265,92 @@ func (c *Client) handleEvent(evt any) {
 		c.connected = true
 		c.mu.Unlock()
 		c.eventChan <- Event{Type: EventTypeConnected}
+		// A new connection pings the phone afresh and says again if it's offline
+		c.updatePhone(func(s *PhoneStatus) { s.Offline = false })
 
-	case *gmproto.Message:
-		msg := convertMessage(e, e.GetConversationID())
+	case *libgm.WrappedMessage:
+		// Deleting a message on the phone sends it again in the deleted state
+		if e.GetMessageStatus().GetStatus() == gmproto.MessageStatusType_MESSAGE_DELETED {
+			c.store.RemoveMessage(e.GetConversationID(), e.GetMessageID())
+			c.eventChan <- Event{
+				Type:    EventTypeMessageDeleted,
+				Message: &store.Message{ID: e.GetMessageID(), ConversationID: e.GetConversationID()},
+			}
+			return
+		}
+
+		// libgm sends a message again every time its status changes
+		msg := convertMessage(e.Message, e.GetConversationID())
 		if msg != nil {
-			c.store.AddMessage(msg)
+			c.attachCachedMedia(msg)
+			c.resolveNames(msg)
+			evtType := EventTypeMessageUpdated
+			if c.store.AddMessage(msg) {
+				evtType = EventTypeNewMessage
+			}
 			c.eventChan <- Event{
-				Type:    EventTypeNewMessage,
+				Type:    evtType,
 				Message: msg,
 			}
 		}
 
-	case *events.AccountChange:
-		// Account state changed, refresh conversations
-		c.eventChan <- Event{
-			Type: EventTypeConversationsUpdated,
+	case *gmproto.Conversation:
+		// The phone sends a conversation again whenever it changes, including
+		// moves between the inbox, the archive and the spam folder
+		conv := convertConversation(e)
+		if conv != nil {
+			c.store.ResolveConversation(conv)
+			c.store.UpdateConversation(conv)
+			c.eventChan <- Event{
+				Type:         EventTypeConversationsUpdated,
+				Conversation: conv,
+			}
 		}
+
+	case *gmproto.TypingData:
+		c.handleTyping(e)
+
+	case *gmproto.Settings:
+		c.handleSettings(e)
+
+	case *events.PhoneNotResponding:
+		c.updatePhone(func(s *PhoneStatus) { s.Offline = true })
+
+	case *events.PhoneRespondingAgain:
+		c.updatePhone(func(s *PhoneStatus) { s.Offline = false })
+
+	case *gmproto.UserAlertEvent:
+		c.handleUserAlert(e)
+
+	case *events.AuthTokenRefreshed:
+		c.handleTokenRefreshed()
+
+	case *events.AccountChange:
+		// Account state changed, the contacts may have too
+		go c.refreshContacts()
 	}
 }
 
-// ListConversations fetches the list of conversations
+// ListConversations fetches the most recent conversations and returns every
+// conversation loaded so far, including older pages
 func (c *Client) ListConversations(ctx context.Context) ([]*store.Conversation, error) {
 	log.Printf("Client: ListConversations called")
+	if _, err := c.ListConversationsPage(ctx, ""); err != nil {
+		return nil, err
+	}
+	return c.store.GetConversations(), nil
+}
+
+// ListConversationsPage fetches the page of conversations following cursor,
+// or the first page if cursor is empty. The fetched conversations are merged
+// into the store.
+//
+// libgm does not expose the protocol's conversation cursor, so the cursor is
+// the size of the window fetched so far and each page doubles the window.
+// Every page fetches the whole window again, so reaching the n-th conversation
+// transfers about 2n. The window stops at maxConversationWindow, and
+// conversations older than that aren't listed.
+func (c *Client) ListConversationsPage(ctx context.Context, cursor string) (*ConversationPage, error) {
 	c.mu.RLock()
 	client := c.client
 	c.mu.RUnlock()
@@ -139,8 +360,25 @@ func (c *Client) ListConversations(ctx context.Context) ([]*store.Conversation,
 		return nil, fmt.Errorf("client not connected")
 	}
 
-	log.Printf("Client: Calling libgm ListConversations...")
-	resp, err := client.ListConversations(25, gmproto.ListConversationsRequest_UNKNOWN)
+	loaded := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid conversation cursor %q", cursor)
+		}
+		loaded = n
+	}
+
+	count := conversationPageSize
+	if loaded > 0 {
+		count = min(loaded*2, maxConversationWindow)
+	}
+	if count <= loaded {
+		return &ConversationPage{}, nil
+	}
+
+	log.Printf("Client: Calling libgm ListConversations with count %d...", count)
+	resp, err := client.ListConversations(count, gmproto.ListConversationsRequest_UNKNOWN)
 	if err != nil {
 		log.Printf("Client: ListConversations error: %v", err)
 		return nil, fmt.Errorf("failed to list conversations: %w", err)
@@ -150,17 +388,45 @@ func (c *Client) ListConversations(ctx context.Context) ([]*store.Conversation,
 	convs := make([]*store.Conversation, 0, len(resp.GetConversations()))
 	for _, conv := range resp.GetConversations() {
 		if converted := convertConversation(conv); converted != nil {
+			c.store.ResolveConversation(converted)
 			convs = append(convs, converted)
 		}
 	}
-
-	log.Printf("Client: Converted %d conversations", len(convs))
 	c.store.SetConversations(convs)
-	return convs, nil
+	// Archived conversations are a folder of their own. Later changes to it
+	// arrive as conversation events, see handleEvent, so it is only listed
+	// once. The spam & blocked folder waits until it is opened, see
+	// LoadBlockedConversations.
+	if loaded == 0 {
+		c.loadArchive(client)
+	}
+
+	page := &ConversationPage{}
+	if loaded < len(convs) {
+		page.Conversations = convs[loaded:]
+	}
+	// A full window means there may be older conversations left, unless it
+	// can't grow any further
+	if len(resp.GetConversations()) >= count && count < maxConversationWindow {
+		page.NextCursor = strconv.Itoa(count)
+	}
+
+	log.Printf("Client: Converted %d conversations, next cursor %q", len(convs), page.NextCursor)
+	return page, nil
 }
 
-// GetMessages fetches messages for a conversation
+// GetMessages refreshes the newest messages of a conversation and returns its
+// whole loaded history, oldest first
 func (c *Client) GetMessages(ctx context.Context, conversationID string) ([]*store.Message, error) {
+	if _, err := c.GetMessagesPage(ctx, conversationID, ""); err != nil {
+		return nil, err
+	}
+	return c.store.GetMessages(conversationID), nil
+}
+
+// GetMessagesPage fetches the page of messages older than cursor, or the newest
+// page if cursor is empty, and merges it into the store
+func (c *Client) GetMessagesPage(ctx context.Context, conversationID string, cursor string) (*MessagePage, error) {
 	c.mu.RLock()
 	client := c.client
 	c.mu.RUnlock()
@@ -169,19 +435,59 @@ func (c *Client) GetMessages(ctx context.Context, conversationID string) ([]*sto
 		return nil, fmt.Errorf("client not connected")
 	}
 
-	resp, err := client.FetchMessages(conversationID, 50, nil)
+	var pbCursor *gmproto.Cursor
+	if cursor != "" {
+		var err error
+		if pbCursor, err = parseMessageCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := client.FetchMessages(conversationID, messagePageSize, pbCursor)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch messages: %w", err)
 	}
 
-	msgs := convertMessages(resp.GetMessages(), conversationID)
-	c.store.SetMessages(conversationID, msgs)
+	// libgm returns the newest message first
+	raw := resp.GetMessages()
+	slices.Reverse(raw)
+	msgs := convertMessages(raw, conversationID)
+	c.attachCachedMedia(msgs...)
+	c.resolveNames(msgs...)
+	c.store.MergeMessages(conversationID, msgs)
+
+	page := &MessagePage{Messages: msgs}
+	if next := resp.GetCursor(); next != nil && next.GetLastItemID() != "" && len(msgs) > 0 {
+		page.NextCursor = makeMessageCursor(next)
+	}
+	return page, nil
+}
 
-	return msgs, nil
+// makeMessageCursor encodes a libgm pagination cursor as an opaque string
+func makeMessageCursor(cursor *gmproto.Cursor) string {
+	return fmt.Sprintf("%s:%d", cursor.GetLastItemID(), cursor.GetLastItemTimestamp())
+}
+
+// parseMessageCursor decodes a cursor made by makeMessageCursor
+func parseMessageCursor(cursor string) (*gmproto.Cursor, error) {
+	i := strings.LastIndexByte(cursor, ':')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid message cursor %q", cursor)
+	}
+	ts, err := strconv.ParseInt(cursor[i+1:], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message cursor %q: %w", cursor, err)
+	}
+	return &gmproto.Cursor{
+		LastItemID:        cursor[:i],
+		LastItemTimestamp: ts,
+	}, nil
 }
 
-// SendMessage sends a text message to a conversation
-func (c *Client) SendMessage(ctx context.Context, conversationID string, text string) error {
+// SendMessage sends a text message to a conversation, as a reply to
+// replyToID if set, from the SIM simID or the conversation's SIM if empty.
+// The message comes back from the phone carrying tmpID.
+func (c *Client) SendMessage(ctx context.Context, conversationID string, text string, tmpID string, replyToID string, simID string) error {
 	c.mu.RLock()
 	client := c.client
 	c.mu.RUnlock()
@@ -189,27 +495,84 @@ func (c *Client) SendMessage(ctx context.Context, conversationID string, text st
 	if client == nil {
 		return fmt.Errorf("client not connected")
 	}
+	if c.phoneOffline() {
+		return ErrPhoneOffline
+	}
 
 	// Create the message request
 	req := &gmproto.SendMessageRequest{
 		ConversationID: conversationID,
 		MessagePayload: &gmproto.MessagePayload{
+			TmpID:          tmpID,
+			TmpID2:         tmpID,
+			ConversationID: conversationID,
 			MessagePayloadContent: &gmproto.MessagePayloadContent{
 				MessageContent: &gmproto.MessageContent{
 					Content: text,
 				},
 			},
 		},
+		TmpID: tmpID,
+	}
+	if replyToID != "" {
+		req.Reply = &gmproto.ReplyPayload{MessageID: replyToID}
 	}
+	c.setSIM(req, simID)
 
-	_, err := client.SendMessage(req)
+	resp, err := client.SendMessage(req)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
+	if resp.GetStatus() != gmproto.SendMessageResponse_SUCCESS {
+		return fmt.Errorf("failed to send message: %s", resp.GetStatus())
+	}
 
 	return nil
 }
 
+// DownloadMedia downloads and decrypts a message's attachment into the media
+// cache and returns its local path. Cached attachments are not downloaded again.
+func (c *Client) DownloadMedia(ctx context.Context, msg *store.Message) (string, error) {
+	if !msg.HasMedia() {
+		return "", fmt.Errorf("message has no attachment")
+	}
+
+	if path, ok := c.store.CachedMedia(msg.MediaID); ok {
+		return path, nil
+	}
+
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return "", fmt.Errorf("client not connected")
+	}
+
+	data, err := client.DownloadMedia(msg.MediaID, msg.MediaKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to download media: %w", err)
+	}
+
+	path, err := c.store.CacheMedia(msg.MediaID, msg.MediaName, msg.MediaType, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to cache media: %w", err)
+	}
+	return path, nil
+}
+
+// attachCachedMedia points messages at attachments that were downloaded before
+func (c *Client) attachCachedMedia(msgs ...*store.Message) {
+	for _, msg := range msgs {
+		if !msg.HasMedia() {
+			continue
+		}
+		if path, ok := c.store.CachedMedia(msg.MediaID); ok {
+			msg.MediaURL = path
+		}
+	}
+}
+
 // MarkRead marks a conversation as read
 func (c *Client) MarkRead(ctx context.Context, conversationID string, messageID string) error {
 	c.mu.RLock()
@@ -228,8 +591,8 @@ func (c *Client) MarkRead(ctx context.Context, conversationID string, messageID
 	return nil
 }
 
-// SendReaction sends a reaction to a message
-func (c *Client) SendReaction(ctx context.Context, conversationID string, messageID string, emoji string) error {
+// SendReaction adds, removes or switches the user's reaction on a message
+func (c *Client) SendReaction(ctx context.Context, conversationID string, messageID string, emoji string, action ReactionAction) error {
 	c.mu.RLock()
 	client := c.client
 	c.mu.RUnlock()
@@ -241,19 +604,52 @@ func (c *Client) SendReaction(ctx context.Context, conversationID string, messag
 	req := &gmproto.SendReactionRequest{
 		MessageID:    messageID,
 		ReactionData: gmproto.MakeReactionData(emoji),
-		Action:       gmproto.SendReactionRequest_ADD,
+		Action:       action.protoAction(),
 	}
 
-	_, err := client.SendReaction(req)
+	resp, err := client.SendReaction(req)
 	if err != nil {
 		return fmt.Errorf("failed to send reaction: %w", err)
 	}
+	if !resp.GetSuccess() {
+		return fmt.Errorf("failed to send reaction: rejected by phone")
+	}
+
+	if action == ReactionRemove {
+		emoji = ""
+	}
+	c.store.SetMyReaction(conversationID, messageID, emoji)
+	return nil
+}
+
+// DeleteMessage deletes a message on the phone and drops it from the cache
+func (c *Client) DeleteMessage(ctx context.Context, conversationID string, messageID string) error {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return fmt.Errorf("client not connected")
+	}
+
+	resp, err := client.DeleteMessage(messageID)
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	if !resp.GetSuccess() {
+		return fmt.Errorf("failed to delete message: rejected by phone")
+	}
 
+	c.store.RemoveMessage(conversationID, messageID)
 	return nil
 }
 
 // Close closes the client and cleans up resources
 func (c *Client) Close() {
+	c.supervisor.Stop()
+	close(c.done)
+	// Keep whatever libgm changed since the last check
+	c.saveAuthData()
 	c.Disconnect()
 	close(c.eventChan)
 }
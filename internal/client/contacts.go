@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/gmproto"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// ListContacts fetches the phone's contact list and saves it in the store,
+// naming conversations and senders after it
+func (c *Client) ListContacts(ctx context.Context) ([]*store.Contact, error) {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return nil, fmt.Errorf("client not connected")
+	}
+
+	resp, err := client.ListContacts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contacts: %w", err)
+	}
+
+	contacts := make([]*store.Contact, 0, len(resp.GetContacts()))
+	for _, contact := range resp.GetContacts() {
+		if converted := convertContact(contact); converted != nil {
+			contacts = append(contacts, converted)
+		}
+	}
+	if err := c.store.SetContacts(contacts); err != nil {
+		// The names still apply until the app exits
+		log.Printf("Client: failed to save contacts: %v", err)
+	}
+	return contacts, nil
+}
+
+// refreshContacts syncs the contact list again and has the UI reload the
+// conversations under their new names
+func (c *Client) refreshContacts() {
+	if _, err := c.ListContacts(context.Background()); err != nil {
+		log.Printf("Client: contact sync error: %v", err)
+	} else {
+		c.eventChan <- Event{Type: EventTypeContactsUpdated}
+	}
+	c.eventChan <- Event{Type: EventTypeConversationsUpdated}
+}
+
+// resolveNames fills in the names of message senders and of everyone who
+// reacted from the conversation's participants and the contacts
+func (c *Client) resolveNames(msgs ...*store.Message) {
+	for _, msg := range msgs {
+		c.store.ResolveMessage(msg)
+	}
+}
+
+// CreateConversation opens the conversation with the given phone numbers,
+// creating it if needed. Several numbers make a group.
+func (c *Client) CreateConversation(ctx context.Context, numbers []string) (*store.Conversation, error) {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return nil, fmt.Errorf("client not connected")
+	}
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("failed to create conversation: no recipients")
+	}
+
+	req := &gmproto.GetOrCreateConversationRequest{
+		Numbers: make([]*gmproto.ContactNumber, len(numbers)),
+	}
+	for i, number := range numbers {
+		req.Numbers[i] = &gmproto.ContactNumber{
+			MysteriousInt: 2,
+			Number:        number,
+			Number2:       number,
+		}
+	}
+
+	resp, err := client.GetOrCreateConversation(req)
+	// The phone asks again before creating an RCS group
+	if err == nil && resp.GetStatus() == gmproto.GetOrCreateConversationResponse_CREATE_RCS {
+		groupName := ""
+		createGroup := true
+		req.RCSGroupName = &groupName
+		req.CreateRCSGroup = &createGroup
+		resp, err = client.GetOrCreateConversation(req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+	if resp.GetConversation().GetConversationID() == "" {
+		return nil, fmt.Errorf("failed to create conversation: no conversation in response (status %s)", resp.GetStatus())
+	}
+
+	conv := convertConversation(resp.GetConversation())
+	c.store.ResolveConversation(conv)
+	// A brand new thread has no messages to sort it by; show it first
+	if conv.LatestTimestamp.IsZero() {
+		conv.LatestTimestamp = time.Now()
+	}
+	c.store.UpdateConversation(conv)
+	return conv, nil
+}
+
+// convertContact converts a libgm contact to our store format
+func convertContact(contact *gmproto.Contact) *store.Contact {
+	number := contact.GetNumber().GetNumber()
+	if number == "" {
+		return nil
+	}
+	formatted := contact.GetNumber().GetFormattedNumber()
+	if formatted == "" {
+		formatted = number
+	}
+	return &store.Contact{
+		ID:              contact.GetContactID(),
+		Name:            contact.GetName(),
+		Number:          number,
+		FormattedNumber: formatted,
+	}
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mau.fi/mautrix-gmessages/pkg/libgm"
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/gmproto"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// loadFolder merges the newest conversations of the archive or the spam &
+// blocked folder into the store
+func (c *Client) loadFolder(client *libgm.Client, folder gmproto.ListConversationsRequest_Folder) error {
+	resp, err := client.ListConversations(conversationPageSize, folder)
+	if err != nil {
+		return fmt.Errorf("failed to list %s conversations: %w", folder, err)
+	}
+
+	convs := make([]*store.Conversation, 0, len(resp.GetConversations()))
+	for _, conv := range resp.GetConversations() {
+		if converted := convertConversation(conv); converted != nil {
+			switch folder {
+			case gmproto.ListConversationsRequest_ARCHIVE:
+				converted.Archived = true
+			case gmproto.ListConversationsRequest_SPAM_BLOCKED:
+				converted.Blocked = true
+			}
+			c.store.ResolveConversation(converted)
+			convs = append(convs, converted)
+		}
+	}
+	c.store.SetConversations(convs)
+	return nil
+}
+
+// loadArchive lists the archive the first time the inbox is loaded
+func (c *Client) loadArchive(client *libgm.Client) {
+	c.mu.Lock()
+	loaded := c.archiveLoaded
+	c.archiveLoaded = true
+	c.mu.Unlock()
+	if loaded {
+		return
+	}
+
+	if err := c.loadFolder(client, gmproto.ListConversationsRequest_ARCHIVE); err != nil {
+		// The inbox is still usable without the archive, and the next
+		// refresh tries again
+		log.Printf("Client: %v", err)
+		c.mu.Lock()
+		c.archiveLoaded = false
+		c.mu.Unlock()
+	}
+}
+
+// LoadBlockedConversations lists the spam & blocked folder into the store
+func (c *Client) LoadBlockedConversations(ctx context.Context) error {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return fmt.Errorf("client not connected")
+	}
+	return c.loadFolder(client, gmproto.ListConversationsRequest_SPAM_BLOCKED)
+}
+
+// ArchiveConversation archives or unarchives a conversation on the phone
+func (c *Client) ArchiveConversation(ctx context.Context, conversationID string, archived bool) error {
+	status := gmproto.ConversationStatus_ACTIVE
+	if archived {
+		status = gmproto.ConversationStatus_ARCHIVED
+	}
+	err := c.updateConversation(conversationID, &gmproto.UpdateConversationData{
+		ConversationID: conversationID,
+		Data:           &gmproto.UpdateConversationData_Status{Status: status},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive conversation: %w", err)
+	}
+
+	c.store.SetArchived(conversationID, archived)
+	return nil
+}
+
+// MuteConversation mutes or unmutes a conversation's notifications on the phone
+func (c *Client) MuteConversation(ctx context.Context, conversationID string, muted bool) error {
+	mute := gmproto.ConversationMuteStatus_UNMUTE
+	if muted {
+		mute = gmproto.ConversationMuteStatus_MUTE
+	}
+	err := c.updateConversation(conversationID, &gmproto.UpdateConversationData{
+		ConversationID: conversationID,
+		Data:           &gmproto.UpdateConversationData_Mute{Mute: mute},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mute conversation: %w", err)
+	}
+	return nil
+}
+
+// BlockConversation blocks the other side of a conversation on the phone,
+// reporting it as spam if report is set, which moves it to the spam & blocked
+// folder
+func (c *Client) BlockConversation(ctx context.Context, conversationID string, report bool) error {
+	action := gmproto.ConversationActionStatus_BLOCK
+	if report {
+		action = gmproto.ConversationActionStatus_BLOCK_AND_REPORT
+	}
+	if err := c.conversationAction(conversationID, action); err != nil {
+		return fmt.Errorf("failed to block conversation: %w", err)
+	}
+
+	c.store.SetBlocked(conversationID, true)
+	return nil
+}
+
+// UnblockConversation unblocks a conversation on the phone, moving it back to
+// the inbox
+func (c *Client) UnblockConversation(ctx context.Context, conversationID string) error {
+	if err := c.conversationAction(conversationID, gmproto.ConversationActionStatus_UNBLOCK); err != nil {
+		return fmt.Errorf("failed to unblock conversation: %w", err)
+	}
+
+	c.store.SetBlocked(conversationID, false)
+	return nil
+}
+
+// conversationAction asks the phone to block or unblock a conversation
+func (c *Client) conversationAction(conversationID string, action gmproto.ConversationActionStatus) error {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return fmt.Errorf("client not connected")
+	}
+
+	resp, err := client.UpdateConversation(&gmproto.UpdateConversationRequest{
+		Action:         action,
+		ConversationID: conversationID,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.GetSuccess() {
+		return fmt.Errorf("rejected by phone")
+	}
+	return nil
+}
+
+// updateConversation sends a conversation settings change to the phone
+func (c *Client) updateConversation(conversationID string, data *gmproto.UpdateConversationData) error {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return fmt.Errorf("client not connected")
+	}
+
+	resp, err := client.UpdateConversation(&gmproto.UpdateConversationRequest{
+		ConversationID: conversationID,
+		Data:           &gmproto.UpdateConversationRequest_UpdateData{UpdateData: data},
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.GetSuccess() {
+		return fmt.Errorf("rejected by phone")
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"time"
 
+	"go.mau.fi/mautrix-gmessages/pkg/libgm"
 	"go.mau.fi/mautrix-gmessages/pkg/libgm/gmproto"
 
 	"github.com/n0ko/messages-tui/internal/store"
@@ -15,9 +16,17 @@ const (
 	EventTypeUnknown EventType = iota
 	EventTypeConnected
 	EventTypeDisconnected
+	EventTypeReconnecting
+	EventTypeReconnected
 	EventTypeNewMessage
 	EventTypeMessageUpdated
+	EventTypeMessageDeleted
 	EventTypeConversationsUpdated
+	EventTypeContactsUpdated
+	EventTypeSIMsUpdated
+	EventTypePhoneStatus
+	EventTypeSessionExpiring
+	EventTypeSessionRefreshed
 	EventTypeTypingIndicator
 	EventTypeReadReceipt
 	EventTypeError
@@ -38,12 +47,19 @@ func convertConversation(conv *gmproto.Conversation) *store.Conversation {
 		return nil
 	}
 
+	participants := make([]store.Participant, 0, len(conv.GetParticipants()))
+	for _, p := range conv.GetParticipants() {
+		if converted := convertParticipant(p); converted.ID != "" {
+			participants = append(participants, converted)
+		}
+	}
+
 	name := conv.GetName()
-	if name == "" && len(conv.GetParticipants()) > 0 {
+	if name == "" {
 		// Use first participant's name if no conversation name
-		for _, p := range conv.GetParticipants() {
-			if p.GetFormattedNumber() != "" {
-				name = p.GetFormattedNumber()
+		for _, p := range participants {
+			if !p.IsMe && p.DisplayName() != "" {
+				name = p.DisplayName()
 				break
 			}
 		}
@@ -62,13 +78,6 @@ func convertConversation(conv *gmproto.Conversation) *store.Conversation {
 		latestTime = time.UnixMicro(ts)
 	}
 
-	participants := make([]string, 0, len(conv.GetParticipants()))
-	for _, p := range conv.GetParticipants() {
-		if p.GetFormattedNumber() != "" {
-			participants = append(participants, p.GetFormattedNumber())
-		}
-	}
-
 	return &store.Conversation{
 		ID:              conv.GetConversationID(),
 		Name:            name,
@@ -77,6 +86,40 @@ func convertConversation(conv *gmproto.Conversation) *store.Conversation {
 		Unread:          conv.GetUnread(),
 		IsGroup:         conv.GetIsGroupChat(),
 		Participants:    participants,
+		Archived:        isArchived(conv.GetStatus()),
+		Blocked:         isBlocked(conv.GetStatus()),
+		OutgoingID:      conv.GetDefaultOutgoingID(),
+	}
+}
+
+// isArchived returns whether a conversation with the given status is in the
+// phone's archive
+func isArchived(status gmproto.ConversationStatus) bool {
+	return status == gmproto.ConversationStatus_ARCHIVED ||
+		status == gmproto.ConversationStatus_KEEP_ARCHIVED
+}
+
+// isBlocked returns whether a conversation with the given status is in the
+// phone's spam & blocked folder
+func isBlocked(status gmproto.ConversationStatus) bool {
+	return status == gmproto.ConversationStatus_SPAM_FOLDER ||
+		status == gmproto.ConversationStatus_BLOCKED_FOLDER
+}
+
+// convertParticipant converts a libgm conversation participant to our store
+// format
+func convertParticipant(p *gmproto.Participant) store.Participant {
+	number := p.GetID().GetNumber()
+	if number == "" {
+		number = p.GetFormattedNumber()
+	}
+	return store.Participant{
+		ID:              p.GetID().GetParticipantID(),
+		Name:            p.GetFullName(),
+		Number:          number,
+		FormattedNumber: p.GetFormattedNumber(),
+		IsMe:            p.GetIsMe(),
+		Color:           p.GetAvatarHexColor(),
 	}
 }
 
@@ -91,14 +134,15 @@ func convertMessage(msg *gmproto.Message, conversationID string) *store.Message
 		timestamp = time.UnixMicro(ts)
 	}
 
-	// Get content from MessageInfo using GetMessageContent()
+	// Get text and the first attachment from MessageInfo
 	content := ""
-	if infos := msg.GetMessageInfo(); len(infos) > 0 {
-		for _, info := range infos {
-			if msgContent := info.GetMessageContent(); msgContent != nil {
-				content = msgContent.GetContent()
-				break
-			}
+	var media *gmproto.MediaContent
+	for _, info := range msg.GetMessageInfo() {
+		if msgContent := info.GetMessageContent(); msgContent != nil && content == "" {
+			content = msgContent.GetContent()
+		}
+		if mediaContent := info.GetMediaContent(); mediaContent != nil && media == nil {
+			media = mediaContent
 		}
 	}
 
@@ -118,9 +162,13 @@ func convertMessage(msg *gmproto.Message, conversationID string) *store.Message
 
 	// Get sender info
 	senderID := msg.GetParticipantID()
-	senderName := ""
+	senderName, senderNumber := "", ""
 	if sender := msg.GetSenderParticipant(); sender != nil {
 		senderName = sender.GetFormattedNumber()
+		senderNumber = sender.GetID().GetNumber()
+		if senderNumber == "" {
+			senderNumber = senderName
+		}
 	}
 
 	// Determine if from me (outgoing messages have status >= 100)
@@ -129,22 +177,47 @@ func convertMessage(msg *gmproto.Message, conversationID string) *store.Message
 		isFromMe = msgStatus.GetStatus().Number() >= 100
 	}
 
-	return &store.Message{
+	converted := &store.Message{
 		ID:             msg.GetMessageID(),
+		TmpID:          msg.GetTmpID(),
 		ConversationID: conversationID,
 		SenderID:       senderID,
 		SenderName:     senderName,
+		SenderNumber:   senderNumber,
 		Content:        content,
 		Timestamp:      timestamp,
 		IsFromMe:       isFromMe,
 		Status:         status,
+		Reactions:      convertReactions(msg.GetReactions()),
+		ReplyToID:      msg.GetReplyMessage().GetMessageID(),
 	}
+	if media != nil {
+		setMedia(converted, media)
+	}
+	return converted
+}
+
+// setMedia copies attachment metadata from a libgm media content
+func setMedia(msg *store.Message, media *gmproto.MediaContent) {
+	mimeType := media.GetMimeType()
+	if mimeType == "" {
+		mimeType = libgm.FormatToMediaType[media.GetFormat()].Format
+	}
+
+	msg.MediaID = media.GetMediaID()
+	msg.MediaName = media.GetMediaName()
+	msg.MediaSize = media.GetSize()
+	msg.MediaType = mimeType
+	msg.MediaKey = media.GetDecryptionKey()
 }
 
 // convertMessages converts a slice of libgm messages
 func convertMessages(msgs []*gmproto.Message, conversationID string) []*store.Message {
 	result := make([]*store.Message, 0, len(msgs))
 	for _, msg := range msgs {
+		if msg.GetMessageStatus().GetStatus() == gmproto.MessageStatusType_MESSAGE_DELETED {
+			continue
+		}
 		if converted := convertMessage(msg, conversationID); converted != nil {
 			result = append(result, converted)
 		}
@@ -0,0 +1,974 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// FakeIncoming is a scripted message delivered by the fake backend
+type FakeIncoming struct {
+	// After is how long to wait after the previous scripted message
+	After time.Duration
+	// Message is the message to deliver; ID and Timestamp are filled in if empty
+	Message *store.Message
+}
+
+// FakePhoneChange is a scripted change in what the phone says about itself
+type FakePhoneChange struct {
+	// After is how long to wait after the previous change
+	After time.Duration
+	// Status is the phone's new status
+	Status PhoneStatus
+}
+
+// FakeScript describes the data served by the fake backend
+type FakeScript struct {
+	Conversations []*store.Conversation
+	Messages      map[string][]*store.Message // keyed by conversation ID
+	Incoming      []FakeIncoming
+	Contacts      []*store.Contact
+	SIMs          []store.SIM
+	Phone         []FakePhoneChange // the first applies at start
+	Media         map[string][]byte // attachment contents keyed by media ID
+	FailWord      string            // outgoing messages containing this fail to send
+}
+
+// Fake is an in-memory Messenger with scripted conversations and incoming
+// messages, for developing and demoing the UI without a phone or network
+type Fake struct {
+	mu            sync.RWMutex
+	store         *store.Store
+	script        *FakeScript
+	conversations map[string]*store.Conversation
+	messages      map[string][]*store.Message
+	eventChan     chan Event
+	connected     bool
+	phone         PhoneStatus
+	nextID        int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewFake creates a fake backend serving the given script
+func NewFake(st *store.Store, script *FakeScript) *Fake {
+	f := &Fake{
+		store:         st,
+		script:        script,
+		conversations: make(map[string]*store.Conversation),
+		messages:      make(map[string][]*store.Message),
+		eventChan:     make(chan Event, 100),
+	}
+
+	for _, conv := range script.Conversations {
+		c := *conv
+		f.conversations[c.ID] = &c
+	}
+	for convID, msgs := range script.Messages {
+		for _, msg := range msgs {
+			m := *msg
+			f.messages[convID] = append(f.messages[convID], &m)
+		}
+	}
+
+	return f
+}
+
+// Start marks the fake as connected and begins delivering scripted messages
+func (f *Fake) Start(ctx context.Context, onQR func(url string)) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	f.mu.Lock()
+	f.connected = true
+	f.ctx = ctx
+	f.cancel = cancel
+	f.mu.Unlock()
+
+	if len(f.script.SIMs) > 0 {
+		f.store.SetSIMs(f.script.SIMs)
+		f.emit(Event{Type: EventTypeSIMsUpdated})
+	}
+
+	f.wg.Add(2)
+	go f.runScript(ctx)
+	go f.runPhone(ctx)
+
+	log.Println("Fake: backend started")
+	return nil
+}
+
+// fakeTypingLead is how long the sender of a scripted message is shown typing
+// before the message arrives
+const fakeTypingLead = 3 * time.Second
+
+// runScript delivers scripted incoming messages until ctx is cancelled
+func (f *Fake) runScript(ctx context.Context) {
+	defer f.wg.Done()
+
+	for _, in := range f.script.Incoming {
+		msg := *in.Message
+		f.store.ResolveMessage(&msg)
+
+		wait := in.After
+		if wait > fakeTypingLead && msg.SenderName != "" {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait - fakeTypingLead):
+			}
+			f.emit(Event{
+				Type: EventTypeTypingIndicator,
+				Data: &TypingInfo{
+					ConversationID: msg.ConversationID,
+					Name:           msg.SenderName,
+					Typing:         true,
+				},
+			})
+			wait = fakeTypingLead
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		f.deliver(&msg)
+	}
+}
+
+// runPhone plays the scripted phone status changes until ctx is cancelled
+func (f *Fake) runPhone(ctx context.Context) {
+	defer f.wg.Done()
+
+	for _, change := range f.script.Phone {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(change.After):
+		}
+
+		status := change.Status
+		f.mu.Lock()
+		f.phone = status
+		f.mu.Unlock()
+		f.emit(Event{Type: EventTypePhoneStatus, Data: &status})
+	}
+}
+
+// deliver adds an incoming message to the fake state and emits an event
+func (f *Fake) deliver(msg *store.Message) {
+	f.store.ResolveMessage(msg)
+
+	f.mu.Lock()
+	if msg.ID == "" {
+		msg.ID = f.newID()
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	f.messages[msg.ConversationID] = append(f.messages[msg.ConversationID], msg)
+	if conv, ok := f.conversations[msg.ConversationID]; ok {
+		conv.LatestMessage = msg.Content
+		conv.LatestTimestamp = msg.Timestamp
+		conv.Unread = !msg.IsFromMe
+	}
+	f.mu.Unlock()
+
+	f.store.AddMessage(msg)
+	f.emit(Event{
+		Type:    EventTypeNewMessage,
+		Message: msg,
+	})
+}
+
+// fakeStatusSteps are the status changes of a sent message and when they happen
+var fakeStatusSteps = []struct {
+	after  time.Duration
+	status string
+}{
+	{1 * time.Second, "delivered"},
+	{3 * time.Second, "read"},
+}
+
+// progressStatus walks a sent message through delivered and read like a
+// phone would. Must be called with mutex held.
+func (f *Fake) progressStatus(msg *store.Message) {
+	if f.ctx == nil {
+		return
+	}
+	ctx := f.ctx
+	convID, msgID := msg.ConversationID, msg.ID
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		for _, step := range fakeStatusSteps {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(step.after):
+			}
+			f.setStatus(convID, msgID, step.status)
+		}
+	}()
+}
+
+// setStatus replaces a message with a copy in the new status and emits an update
+func (f *Fake) setStatus(conversationID, messageID, status string) {
+	f.mu.Lock()
+	var updated *store.Message
+	for i, msg := range f.messages[conversationID] {
+		if msg.ID == messageID {
+			m := *msg
+			m.Status = status
+			f.messages[conversationID][i] = &m
+			updated = &m
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	if updated == nil {
+		return
+	}
+	f.store.AddMessage(updated)
+	f.emit(Event{
+		Type:    EventTypeMessageUpdated,
+		Message: updated,
+	})
+}
+
+// emit sends an event, giving up once the fake is closed so goroutines
+// blocked on a full channel nobody drains anymore let Close finish
+func (f *Fake) emit(evt Event) {
+	f.mu.RLock()
+	ctx := f.ctx
+	f.mu.RUnlock()
+	if ctx == nil {
+		f.eventChan <- evt
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	select {
+	case f.eventChan <- evt:
+	case <-ctx.Done():
+	}
+}
+
+// newID returns a fresh message ID. Must be called with mutex held.
+func (f *Fake) newID() string {
+	f.nextID++
+	return fmt.Sprintf("fake-%d", f.nextID)
+}
+
+// EventChannel returns the channel for receiving events
+func (f *Fake) EventChannel() <-chan Event {
+	return f.eventChan
+}
+
+// IsConnected returns whether the fake has been started
+func (f *Fake) IsConnected() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.connected
+}
+
+// ListConversations returns the first page of scripted conversations merged
+// with any pages loaded before
+func (f *Fake) ListConversations(ctx context.Context) ([]*store.Conversation, error) {
+	if _, err := f.ListConversationsPage(ctx, ""); err != nil {
+		return nil, err
+	}
+	return f.store.GetConversations(), nil
+}
+
+// ListConversationsPage returns scripted conversations, newest first, in pages
+// of conversationPageSize. The cursor is the offset of the page.
+func (f *Fake) ListConversationsPage(ctx context.Context, cursor string) (*ConversationPage, error) {
+	offset := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid conversation cursor %q", cursor)
+		}
+		offset = n
+	}
+
+	f.mu.RLock()
+	all := make([]*store.Conversation, 0, len(f.conversations))
+	for _, conv := range f.conversations {
+		c := *conv
+		f.store.ResolveConversation(&c)
+		all = append(all, &c)
+	}
+	f.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].LatestTimestamp.After(all[j].LatestTimestamp)
+	})
+
+	page := &ConversationPage{}
+	if offset < len(all) {
+		end := min(offset+conversationPageSize, len(all))
+		page.Conversations = all[offset:end]
+		if end < len(all) {
+			page.NextCursor = strconv.Itoa(end)
+		}
+	}
+
+	f.store.SetConversations(page.Conversations)
+	return page, nil
+}
+
+// GetMessages returns the newest page of a scripted conversation merged with
+// any older pages loaded before
+func (f *Fake) GetMessages(ctx context.Context, conversationID string) ([]*store.Message, error) {
+	if _, err := f.GetMessagesPage(ctx, conversationID, ""); err != nil {
+		return nil, err
+	}
+	return f.store.GetMessages(conversationID), nil
+}
+
+// GetMessagesPage returns scripted messages in pages of messagePageSize,
+// newest page first. The cursor is the number of messages already returned.
+func (f *Fake) GetMessagesPage(ctx context.Context, conversationID string, cursor string) (*MessagePage, error) {
+	skip := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid message cursor %q", cursor)
+		}
+		skip = n
+	}
+
+	f.mu.RLock()
+	if _, ok := f.conversations[conversationID]; !ok {
+		f.mu.RUnlock()
+		return nil, fmt.Errorf("failed to fetch messages: unknown conversation %s", conversationID)
+	}
+	all := f.messages[conversationID]
+	end := max(len(all)-skip, 0)
+	start := max(end-messagePageSize, 0)
+	msgs := make([]*store.Message, end-start)
+	for i, msg := range all[start:end] {
+		m := *msg
+		f.store.ResolveMessage(&m)
+		msgs[i] = &m
+	}
+	f.mu.RUnlock()
+
+	f.store.MergeMessages(conversationID, msgs)
+
+	page := &MessagePage{Messages: msgs}
+	if start > 0 {
+		page.NextCursor = strconv.Itoa(skip + len(msgs))
+	}
+	return page, nil
+}
+
+// ListContacts returns the scripted contact list and saves it in the store
+func (f *Fake) ListContacts(ctx context.Context) ([]*store.Contact, error) {
+	contacts := make([]*store.Contact, len(f.script.Contacts))
+	for i, contact := range f.script.Contacts {
+		c := *contact
+		contacts[i] = &c
+	}
+	if err := f.store.SetContacts(contacts); err != nil {
+		log.Printf("Fake: failed to save contacts: %v", err)
+	}
+	return contacts, nil
+}
+
+// CreateConversation returns the existing thread with exactly these numbers,
+// or starts a new one
+func (f *Fake) CreateConversation(ctx context.Context, numbers []string) (*store.Conversation, error) {
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("failed to create conversation: no recipients")
+	}
+
+	want := make([]string, len(numbers))
+	for i, number := range numbers {
+		want[i] = digitsOnly(number)
+	}
+	sort.Strings(want)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, conv := range f.conversations {
+		others := conv.Others()
+		have := make([]string, len(others))
+		for i, p := range others {
+			have[i] = digitsOnly(p.Number)
+		}
+		sort.Strings(have)
+		if slices.Equal(have, want) {
+			c := *conv
+			return &c, nil
+		}
+	}
+
+	names := make([]string, len(numbers))
+	participants := []store.Participant{fakeMe}
+	for i, number := range numbers {
+		participants = append(participants, store.Participant{
+			ID:              "p" + digitsOnly(number),
+			Number:          number,
+			FormattedNumber: number,
+		})
+		names[i] = number
+		for _, contact := range f.script.Contacts {
+			if digitsOnly(contact.Number) == digitsOnly(number) {
+				names[i] = contact.Name
+				break
+			}
+		}
+	}
+
+	conv := &store.Conversation{
+		ID:              f.newID(),
+		Name:            strings.Join(names, ", "),
+		LatestTimestamp: time.Now(),
+		IsGroup:         len(numbers) > 1,
+		Participants:    participants,
+		OutgoingID:      fakeMe.ID,
+	}
+	f.conversations[conv.ID] = conv
+	c := *conv
+	f.store.UpdateConversation(&c)
+	return &c, nil
+}
+
+// ArchiveConversation moves a scripted conversation in or out of the archive
+func (f *Fake) ArchiveConversation(ctx context.Context, conversationID string, archived bool) error {
+	f.mu.Lock()
+	conv, ok := f.conversations[conversationID]
+	if ok {
+		conv.Archived = archived
+	}
+	f.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("failed to archive conversation: unknown conversation %s", conversationID)
+	}
+	f.store.SetArchived(conversationID, archived)
+	return nil
+}
+
+// MuteConversation accepts mutes; the fake never notifies anyway
+func (f *Fake) MuteConversation(ctx context.Context, conversationID string, muted bool) error {
+	f.mu.RLock()
+	_, ok := f.conversations[conversationID]
+	f.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("failed to mute conversation: unknown conversation %s", conversationID)
+	}
+	return nil
+}
+
+// BlockConversation moves a scripted conversation to the spam & blocked folder
+func (f *Fake) BlockConversation(ctx context.Context, conversationID string, report bool) error {
+	if err := f.setBlocked(conversationID, true); err != nil {
+		return fmt.Errorf("failed to block conversation: %w", err)
+	}
+	return nil
+}
+
+// UnblockConversation moves a scripted conversation back to the inbox
+func (f *Fake) UnblockConversation(ctx context.Context, conversationID string) error {
+	if err := f.setBlocked(conversationID, false); err != nil {
+		return fmt.Errorf("failed to unblock conversation: %w", err)
+	}
+	return nil
+}
+
+// LoadBlockedConversations caches every scripted conversation in the spam &
+// blocked folder
+func (f *Fake) LoadBlockedConversations(ctx context.Context) error {
+	f.mu.RLock()
+	var convs []*store.Conversation
+	for _, conv := range f.conversations {
+		if conv.Blocked {
+			c := *conv
+			f.store.ResolveConversation(&c)
+			convs = append(convs, &c)
+		}
+	}
+	f.mu.RUnlock()
+
+	f.store.SetConversations(convs)
+	return nil
+}
+
+// setBlocked blocks or unblocks a scripted conversation and its cached copy.
+// Unblocked conversations go back to the inbox.
+func (f *Fake) setBlocked(conversationID string, blocked bool) error {
+	f.mu.Lock()
+	conv, ok := f.conversations[conversationID]
+	if ok {
+		conv.Blocked = blocked
+		if !blocked {
+			conv.Archived = false
+		}
+	}
+	f.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("unknown conversation %s", conversationID)
+	}
+	f.store.SetBlocked(conversationID, blocked)
+	return nil
+}
+
+// hasSIM returns whether simID is empty or one of the scripted SIMs
+func (f *Fake) hasSIM(simID string) bool {
+	if simID == "" {
+		return true
+	}
+	for _, sim := range f.script.SIMs {
+		if sim.ParticipantID == simID {
+			return true
+		}
+	}
+	return false
+}
+
+// digitsOnly strips everything but digits from a phone number
+func digitsOnly(number string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+}
+
+// SendMessage records an outgoing message and echoes it back with tmpID like
+// the phone would. Messages containing the script's FailWord are rejected, and
+// nothing goes out while the scripted phone is offline.
+func (f *Fake) SendMessage(ctx context.Context, conversationID string, text string, tmpID string, replyToID string, simID string) error {
+	f.mu.Lock()
+	conv, ok := f.conversations[conversationID]
+	if !ok {
+		f.mu.Unlock()
+		return fmt.Errorf("failed to send message: unknown conversation %s", conversationID)
+	}
+	if f.phone.Offline {
+		f.mu.Unlock()
+		return ErrPhoneOffline
+	}
+	if !f.hasSIM(simID) {
+		f.mu.Unlock()
+		return fmt.Errorf("failed to send message: unknown SIM %s", simID)
+	}
+	if f.script.FailWord != "" && strings.Contains(text, f.script.FailWord) {
+		f.mu.Unlock()
+		return fmt.Errorf("failed to send message: phone rejected the message")
+	}
+
+	msg := &store.Message{
+		ID:             f.newID(),
+		TmpID:          tmpID,
+		ConversationID: conversationID,
+		SenderID:       "me",
+		Content:        text,
+		Timestamp:      time.Now(),
+		IsFromMe:       true,
+		Status:         "sent",
+		ReplyToID:      replyToID,
+	}
+	f.messages[conversationID] = append(f.messages[conversationID], msg)
+	conv.LatestMessage = text
+	conv.LatestTimestamp = msg.Timestamp
+	f.progressStatus(msg)
+	f.mu.Unlock()
+
+	eventType := EventTypeMessageUpdated
+	if f.store.AddMessage(msg) {
+		eventType = EventTypeNewMessage
+	}
+	f.emit(Event{
+		Type:    eventType,
+		Message: msg,
+	})
+	return nil
+}
+
+// SendMedia records an outgoing attachment and echoes it back with tmpID like
+// the phone would, pausing briefly at each stage so the progress display can be seen
+func (f *Fake) SendMedia(ctx context.Context, conversationID string, path string, caption string, tmpID string, simID string, progress func(UploadProgress)) error {
+	f.mu.RLock()
+	offline := f.phone.Offline
+	f.mu.RUnlock()
+	if offline {
+		return ErrPhoneOffline
+	}
+
+	fileName := filepath.Base(path)
+	var size, read int64
+	report := func(stage string, step int) {
+		if progress != nil {
+			progress(UploadProgress{FileName: fileName, Size: size, Read: read, Stage: stage, Step: step, Steps: uploadSteps})
+		}
+	}
+
+	report(UploadStageReading, 1)
+	data, mimeType, err := readAttachment(path, func(n, total int64) {
+		read, size = n, total
+		report(UploadStageReading, 1)
+	})
+	if err != nil {
+		return err
+	}
+
+	for step, stage := range []string{UploadStageUploading, UploadStageSending} {
+		report(stage, step+2)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	f.mu.Lock()
+	conv, ok := f.conversations[conversationID]
+	if !ok {
+		f.mu.Unlock()
+		return fmt.Errorf("failed to send attachment: unknown conversation %s", conversationID)
+	}
+	if !f.hasSIM(simID) {
+		f.mu.Unlock()
+		return fmt.Errorf("failed to send attachment: unknown SIM %s", simID)
+	}
+
+	mediaID := "fake-media-" + f.newID()
+	if f.script.Media == nil {
+		f.script.Media = make(map[string][]byte)
+	}
+	f.script.Media[mediaID] = data
+
+	msg := &store.Message{
+		ID:             f.newID(),
+		TmpID:          tmpID,
+		ConversationID: conversationID,
+		SenderID:       "me",
+		Content:        caption,
+		Timestamp:      time.Now(),
+		IsFromMe:       true,
+		Status:         "sent",
+		MediaID:        mediaID,
+		MediaName:      fileName,
+		MediaType:      mimeType,
+		MediaSize:      size,
+	}
+	f.messages[conversationID] = append(f.messages[conversationID], msg)
+	conv.LatestMessage = caption
+	conv.LatestTimestamp = msg.Timestamp
+	f.progressStatus(msg)
+	f.mu.Unlock()
+
+	eventType := EventTypeMessageUpdated
+	if f.store.AddMessage(msg) {
+		eventType = EventTypeNewMessage
+	}
+	f.emit(Event{
+		Type:    eventType,
+		Message: msg,
+	})
+	return nil
+}
+
+// SendReaction adds, removes or switches the user's reaction on a message
+func (f *Fake) SendReaction(ctx context.Context, conversationID string, messageID string, emoji string, action ReactionAction) error {
+	if action == ReactionRemove {
+		emoji = ""
+	}
+
+	f.mu.Lock()
+	found := false
+	for i, msg := range f.messages[conversationID] {
+		if msg.ID == messageID {
+			f.messages[conversationID][i] = msg.WithMyReaction(emoji)
+			found = true
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	if !found {
+		return fmt.Errorf("failed to send reaction: unknown message %s", messageID)
+	}
+	f.store.SetMyReaction(conversationID, messageID, emoji)
+	return nil
+}
+
+// DeleteMessage removes a message from the fake state
+func (f *Fake) DeleteMessage(ctx context.Context, conversationID string, messageID string) error {
+	f.mu.Lock()
+	found := false
+	msgs := f.messages[conversationID]
+	for i, msg := range msgs {
+		if msg.ID == messageID {
+			f.messages[conversationID] = append(msgs[:i:i], msgs[i+1:]...)
+			found = true
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	if !found {
+		return fmt.Errorf("failed to delete message: unknown message %s", messageID)
+	}
+	f.store.RemoveMessage(conversationID, messageID)
+	return nil
+}
+
+// SetTyping accepts typing notifications; there is nobody to show them to
+func (f *Fake) SetTyping(ctx context.Context, conversationID string) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if _, ok := f.conversations[conversationID]; !ok {
+		return fmt.Errorf("failed to send typing notification: unknown conversation %s", conversationID)
+	}
+	return nil
+}
+
+// DownloadMedia stores a scripted attachment in the media cache
+func (f *Fake) DownloadMedia(ctx context.Context, msg *store.Message) (string, error) {
+	if !msg.HasMedia() {
+		return "", fmt.Errorf("message has no attachment")
+	}
+
+	if path, ok := f.store.CachedMedia(msg.MediaID); ok {
+		return path, nil
+	}
+
+	f.mu.RLock()
+	data, ok := f.script.Media[msg.MediaID]
+	f.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("failed to download media: unknown media %s", msg.MediaID)
+	}
+
+	path, err := f.store.CacheMedia(msg.MediaID, msg.MediaName, msg.MediaType, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to cache media: %w", err)
+	}
+	return path, nil
+}
+
+// MarkRead marks a conversation as read
+func (f *Fake) MarkRead(ctx context.Context, conversationID string, messageID string) error {
+	f.mu.Lock()
+	if conv, ok := f.conversations[conversationID]; ok {
+		conv.Unread = false
+	}
+	f.mu.Unlock()
+
+	f.store.MarkConversationRead(conversationID)
+	return nil
+}
+
+// Close stops the script and closes the event channel
+func (f *Fake) Close() {
+	f.mu.Lock()
+	cancel := f.cancel
+	f.connected = false
+	f.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	f.wg.Wait()
+
+	// Nobody may be reading anymore
+	select {
+	case f.eventChan <- Event{Type: EventTypeDisconnected}:
+	default:
+	}
+	close(f.eventChan)
+}
+
+// fakeCake is the content of the demo attachment
+const fakeCake = `
+    iiii
+   |~~~~|
+   |    |
+ __|____|__
+|__________|
+`
+
+// fakeMe is the user, a participant of every scripted conversation
+var fakeMe = store.Participant{ID: "me", IsMe: true}
+
+// fakeWorkSIM is the user's participant ID on the second scripted SIM
+const fakeWorkSIM = "me-work"
+
+// fakeParticipant makes a scripted participant with a number like the
+// phone's, +1 555-0100 for 100
+func fakeParticipant(id, name string, n int, color string) store.Participant {
+	return store.Participant{
+		ID:              id,
+		Name:            name,
+		Number:          fmt.Sprintf("+1555%04d", n),
+		FormattedNumber: fmt.Sprintf("+1 555-%04d", n),
+		Color:           color,
+	}
+}
+
+// DefaultFakeScript returns a small demo data set for the fake backend
+func DefaultFakeScript() *FakeScript {
+	now := time.Now()
+
+	convs := []*store.Conversation{
+		{
+			ID:           "alice",
+			Name:         "Alice",
+			Unread:       true,
+			Participants: []store.Participant{fakeMe, fakeParticipant("alice", "Alice", 100, "#e91e63")},
+		},
+		{
+			ID:           "bob",
+			Name:         "Bob",
+			Participants: []store.Participant{fakeMe, fakeParticipant("bob", "Bob", 101, "#3f51b5")},
+		},
+		{
+			ID:      "family",
+			Name:    "Family",
+			IsGroup: true,
+			Participants: []store.Participant{
+				fakeMe,
+				fakeParticipant("mom", "Mom", 102, "#43a047"),
+				fakeParticipant("dad", "Dad", 103, "#fb8c00"),
+				fakeParticipant("grandma", "", 106, "#8e24aa"),
+			},
+		},
+	}
+
+	msgs := map[string][]*store.Message{
+		"alice": {
+			{ID: "alice-1", ConversationID: "alice", SenderID: "alice", SenderName: "Alice", Content: "Hey! Are we still on for lunch?", Timestamp: now.Add(-2 * time.Hour)},
+			{ID: "alice-2", ConversationID: "alice", SenderID: "me", Content: "Yes, 12:30 at the usual place", Timestamp: now.Add(-110 * time.Minute), IsFromMe: true, Status: "read"},
+			{ID: "alice-3", ConversationID: "alice", SenderID: "alice", SenderName: "Alice", Content: "Perfect, see you there", Timestamp: now.Add(-105 * time.Minute), Reactions: []store.Reaction{
+				{Emoji: "👍", SenderIDs: []string{"me"}, FromMe: true},
+			}},
+		},
+		"bob": {
+			{ID: "bob-1", ConversationID: "bob", SenderID: "me", Content: "Did you push the fix?", Timestamp: now.Add(-26 * time.Hour), IsFromMe: true, Status: "delivered"},
+			{ID: "bob-2", ConversationID: "bob", SenderID: "bob", SenderName: "Bob", Content: "Not yet, tests are still running", Timestamp: now.Add(-25 * time.Hour)},
+		},
+		"family": {
+			{ID: "family-1", ConversationID: "family", SenderID: "mom", SenderName: "Mom", Content: "Dinner on Sunday?", Timestamp: now.Add(-3 * 24 * time.Hour), Reactions: []store.Reaction{
+				{Emoji: "😍", SenderIDs: []string{"dad", "me"}, Senders: []string{"Dad"}, FromMe: true},
+				{Emoji: "😂", SenderIDs: []string{"grandma"}, Senders: []string{"Grandma"}},
+			}},
+			{ID: "family-2", ConversationID: "family", SenderID: "dad", SenderName: "Dad", Content: "I'll bring dessert", Timestamp: now.Add(-3*24*time.Hour + 10*time.Minute)},
+			{ID: "family-3", ConversationID: "family", SenderID: "dad", SenderName: "Dad", Timestamp: now.Add(-3*24*time.Hour + 12*time.Minute), MediaID: "fake-media-cake", MediaName: "cake.txt", MediaType: "text/plain", MediaSize: int64(len(fakeCake))},
+		},
+	}
+
+	// A long history with Bob to scroll back through
+	var history []*store.Message
+	start := now.Add(-30 * 24 * time.Hour)
+	for i := 0; i < 120; i++ {
+		msg := &store.Message{
+			ID:             fmt.Sprintf("bob-old-%d", i),
+			ConversationID: "bob",
+			Content:        fmt.Sprintf("Build #%d finished", 1000+i),
+			Timestamp:      start.Add(time.Duration(i) * 4 * time.Hour),
+		}
+		if i%2 == 0 {
+			msg.SenderID, msg.SenderName = "bob", "Bob"
+		} else {
+			msg.SenderID, msg.IsFromMe, msg.Status = "me", true, "read"
+			msg.Content = "Thanks!"
+		}
+		history = append(history, msg)
+	}
+	msgs["bob"] = append(history, msgs["bob"]...)
+
+	// Older one-off threads so there is more than one page to scroll through,
+	// a few of them archived or blocked
+	for i := 1; i <= 60; i++ {
+		id := fmt.Sprintf("old-%d", i)
+		convs = append(convs, &store.Conversation{
+			ID:           id,
+			Name:         fmt.Sprintf("+1 555-%04d", 1000+i),
+			Participants: []store.Participant{fakeMe, fakeParticipant(id+"-sender", "", 1000+i, "")},
+			Archived:     i%20 == 0,
+			Blocked:      i%25 == 0,
+		})
+		msgs[id] = []*store.Message{
+			{ID: id + "-1", ConversationID: id, SenderID: id + "-sender", Content: fmt.Sprintf("Your verification code is %06d", i*7919%1000000), Timestamp: now.Add(-time.Duration(7+i) * 24 * time.Hour)},
+		}
+	}
+
+	// Bob is a colleague, texted from the work SIM
+	for _, conv := range convs {
+		conv.OutgoingID = fakeMe.ID
+		if conv.ID == "bob" {
+			conv.OutgoingID = fakeWorkSIM
+		}
+	}
+
+	// Derive conversation previews from the last message of each thread
+	for _, conv := range convs {
+		if thread := msgs[conv.ID]; len(thread) > 0 {
+			last := thread[len(thread)-1]
+			conv.LatestMessage = last.Content
+			conv.LatestTimestamp = last.Timestamp
+		}
+	}
+
+	return &FakeScript{
+		Conversations: convs,
+		Messages:      msgs,
+		Media: map[string][]byte{
+			"fake-media-cake": []byte(fakeCake),
+		},
+		FailWord: "!fail",
+		// On wifi, then out of reach for a while, coming back on data with a
+		// low battery
+		Phone: []FakePhoneChange{
+			{Status: PhoneStatus{Network: NetworkWiFi}},
+			{After: 50 * time.Second, Status: PhoneStatus{Offline: true, Network: NetworkWiFi}},
+			{After: 20 * time.Second, Status: PhoneStatus{Network: NetworkData, BatteryLow: true}},
+		},
+		SIMs: []store.SIM{
+			{ParticipantID: fakeMe.ID, Slot: 1, Carrier: "Personal", FormattedNumber: "+1 555-0001", Color: "#1e88e5"},
+			{ParticipantID: fakeWorkSIM, Slot: 2, Carrier: "Work", FormattedNumber: "+1 555-0002", Color: "#f4511e"},
+		},
+		Contacts: []*store.Contact{
+			{ID: "c-alice", Name: "Alice", Number: "+15550100", FormattedNumber: "+1 555-0100"},
+			{ID: "c-bob", Name: "Bob", Number: "+15550101", FormattedNumber: "+1 555-0101"},
+			{ID: "c-mom", Name: "Mom", Number: "+15550102", FormattedNumber: "+1 555-0102"},
+			{ID: "c-dad", Name: "Dad", Number: "+15550103", FormattedNumber: "+1 555-0103"},
+			{ID: "c-carol", Name: "Carol", Number: "+15550104", FormattedNumber: "+1 555-0104"},
+			{ID: "c-dave", Name: "Dave", Number: "+15550105", FormattedNumber: "+1 555-0105"},
+			{ID: "c-grandma", Name: "Grandma", Number: "+15550106", FormattedNumber: "+1 555-0106"},
+			{ID: "c-pharmacy", Name: "Pharmacy", Number: "+15551001", FormattedNumber: "+1 555-1001"},
+		},
+		Incoming: []FakeIncoming{
+			{After: 5 * time.Second, Message: &store.Message{ConversationID: "bob", SenderID: "bob", SenderName: "Bob", Content: "Tests are green, pushing now", ReplyToID: "bob-1"}},
+			{After: 20 * time.Second, Message: &store.Message{ConversationID: "family", SenderID: "mom", SenderName: "Mom", Content: "Don't forget to bring the photos!"}},
+			// Named from the participant list, by number until the contacts are synced
+			{After: 35 * time.Second, Message: &store.Message{ConversationID: "family", SenderID: "grandma", Content: "I found the old albums"}},
+		},
+	}
+}
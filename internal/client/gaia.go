@@ -0,0 +1,198 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"go.mau.fi/mautrix-gmessages/pkg/libgm"
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/events"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// requiredCookies are the Google account cookies pairing can't do without
+var requiredCookies = []string{"SID", "HSID", "SSID", "APISID", "SAPISID", "OSID"}
+
+// LoadCookies reads the cookies of a browser signed in to Google Messages
+// from a file. The file can be a JSON object of names to values, a JSON list
+// of cookies as browser extensions export them, or a Netscape cookies.txt.
+func LoadCookies(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cookies: %w", err)
+	}
+
+	cookies, err := parseCookies(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cookies in %s: %w", path, err)
+	}
+
+	var missing []string
+	for _, name := range requiredCookies {
+		if cookies[name] == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("cookies in %s are missing %s, export them from a browser signed in to messages.google.com", path, strings.Join(missing, ", "))
+	}
+	return cookies, nil
+}
+
+// parseCookies decodes a cookies file in any of the formats LoadCookies takes
+func parseCookies(data []byte) (map[string]string, error) {
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case bytes.HasPrefix(data, []byte("{")):
+		var cookies map[string]string
+		if err := json.Unmarshal(data, &cookies); err != nil {
+			return nil, err
+		}
+		return cookies, nil
+
+	case bytes.HasPrefix(data, []byte("[")):
+		var list []struct {
+			Name   string `json:"name"`
+			Value  string `json:"value"`
+			Domain string `json:"domain"`
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		cookies := make(map[string]string, len(list))
+		for _, c := range list {
+			if isGoogleDomain(c.Domain) {
+				cookies[c.Name] = c.Value
+			}
+		}
+		return cookies, nil
+	}
+
+	// Netscape format: domain, subdomains, path, secure, expiry, name, value
+	cookies := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		// curl marks HTTP-only cookies with a prefix on an otherwise comment-like line
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("unrecognized line %q", line)
+		}
+		if isGoogleDomain(fields[0]) {
+			cookies[fields[5]] = fields[6]
+		}
+	}
+	return cookies, scanner.Err()
+}
+
+// isGoogleDomain reports whether a cookie domain is sent to Google Messages
+func isGoogleDomain(domain string) bool {
+	domain = strings.TrimPrefix(domain, ".")
+	return domain == "" || domain == "google.com" || domain == "messages.google.com"
+}
+
+// UseGoogleLogin makes Start pair through the Google account the cookies
+// are signed in to instead of a QR code, if there is no session to restore.
+// onEmoji is called with the emoji to tap on the phone.
+func (c *Client) UseGoogleLogin(cookies map[string]string, onEmoji func(emoji string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.googleCookies = cookies
+	c.onEmoji = onEmoji
+}
+
+// PairGoogle pairs with the phone through the Google account the cookies
+// are signed in to. onEmoji is called with the emoji the user has to tap on
+// the phone to confirm. Pairing only sets up the keys, so the returned client
+// needs a fresh connection for messaging, see connectPaired.
+func (a *AuthHandler) PairGoogle(ctx context.Context, cookies map[string]string, onEmoji func(emoji string)) (*libgm.Client, error) {
+	authData := libgm.NewAuthData()
+	authData.SetCookies(cookies)
+
+	client := libgm.NewClient(authData, nil, a.logger)
+	a.client = client
+	client.SetEventHandler(func(evt any) {
+		log.Printf("Auth: event during Google pairing: %T", evt)
+	})
+	// Pairing long-polls for the phone's answer, which must not outlive a
+	// failed attempt
+	fail := func(err error) (*libgm.Client, error) {
+		client.Disconnect()
+		return nil, err
+	}
+
+	if err := client.FetchConfig(ctx); err != nil {
+		return fail(fmt.Errorf("failed to fetch config: %w", err))
+	}
+
+	log.Printf("Auth: starting Google account pairing")
+	emoji, session, err := client.StartGaiaPairing(ctx)
+	if err != nil {
+		return fail(googlePairingError(err))
+	}
+	onEmoji(emoji)
+
+	if _, err := client.FinishGaiaPairing(ctx, session); err != nil {
+		return fail(googlePairingError(err))
+	}
+	log.Printf("Auth: Google account pairing successful")
+
+	data, err := marshalAuthData(authData)
+	if err != nil {
+		return fail(fmt.Errorf("failed to encode session: %w", err))
+	}
+	if err := a.store.SaveSession(&store.Session{DevicePair: data}); err != nil {
+		return fail(fmt.Errorf("failed to save session: %w", err))
+	}
+	return client, nil
+}
+
+// connectPaired hands a client fresh from Google account pairing to c and
+// connects it for messaging. The client is handed over first so no event of
+// the new connection is lost.
+func (c *Client) connectPaired(client *libgm.Client) error {
+	c.SetClient(client)
+	if err := client.Reconnect(); err != nil {
+		client.Disconnect()
+		c.mu.Lock()
+		c.client = nil
+		c.connected = false
+		c.mu.Unlock()
+		return fmt.Errorf("failed to connect after pairing: %w", err)
+	}
+	return nil
+}
+
+// googlePairingError explains why Google account pairing failed
+func googlePairingError(err error) error {
+	switch {
+	case errors.Is(err, libgm.ErrNoCookies):
+		return fmt.Errorf("no cookies to sign in with: %w", err)
+	case errors.Is(err, libgm.ErrNoDevicesFound):
+		return fmt.Errorf("no phone is using Google Messages with this Google account: %w", err)
+	case errors.Is(err, libgm.ErrPairingInitTimeout):
+		return fmt.Errorf("the phone didn't answer, check it's online: %w", err)
+	case errors.Is(err, libgm.ErrIncorrectEmoji):
+		return fmt.Errorf("the wrong emoji was tapped on the phone, try again: %w", err)
+	case errors.Is(err, libgm.ErrPairingCancelled):
+		return fmt.Errorf("pairing was cancelled on the phone: %w", err)
+	case errors.Is(err, libgm.ErrPairingTimeout):
+		return fmt.Errorf("no emoji was tapped on the phone in time, try again: %w", err)
+	case errors.Is(err, events.ErrCallerNoPermission):
+		return fmt.Errorf("the cookies were refused, export them again: %w", err)
+	default:
+		return fmt.Errorf("failed to pair with Google account: %w", err)
+	}
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseCookies(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "JSON map",
+			data: `{"SID": "sid", "OSID": "osid"}`,
+			want: map[string]string{"SID": "sid", "OSID": "osid"},
+		},
+		{
+			name: "JSON list from a browser extension",
+			data: `[
+				{"name": "SID", "value": "sid", "domain": ".google.com"},
+				{"name": "OSID", "value": "osid", "domain": "messages.google.com"},
+				{"name": "NID", "value": "other", "domain": ".youtube.com"}
+			]`,
+			want: map[string]string{"SID": "sid", "OSID": "osid"},
+		},
+		{
+			name: "Netscape cookies.txt",
+			data: "# Netscape HTTP Cookie File\n" +
+				"\n" +
+				".google.com\tTRUE\t/\tTRUE\t1900000000\tSID\tsid\n" +
+				"#HttpOnly_.google.com\tTRUE\t/\tTRUE\t1900000000\tHSID\thsid\n" +
+				"messages.google.com\tFALSE\t/\tTRUE\t1900000000\tOSID\tosid\n" +
+				".example.com\tTRUE\t/\tFALSE\t1900000000\tSID\tother\n",
+			want: map[string]string{"SID": "sid", "HSID": "hsid", "OSID": "osid"},
+		},
+		{
+			name: "surrounding whitespace",
+			data: "\n  {\"SID\": \"sid\"}\n",
+			want: map[string]string{"SID": "sid"},
+		},
+		{
+			name:    "broken JSON map",
+			data:    `{"SID": `,
+			wantErr: true,
+		},
+		{
+			name:    "broken JSON list",
+			data:    `[{"name": "SID"`,
+			wantErr: true,
+		},
+		{
+			name:    "Netscape line with missing fields",
+			data:    ".google.com\tTRUE\t/\tSID\tsid\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCookies([]byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/util"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// Messenger is the backend used by the UI to talk to a messaging service
+type Messenger interface {
+	// Start restores a saved session or pairs a new device. onQR is called
+	// with every pairing URL that should be shown to the user. Start returns
+	// once the backend is connected and ready to use.
+	Start(ctx context.Context, onQR func(url string)) error
+
+	// EventChannel returns the channel for receiving events
+	EventChannel() <-chan Event
+
+	// IsConnected returns whether the backend is connected
+	IsConnected() bool
+
+	// ListConversations refreshes the most recent conversations and returns
+	// every conversation loaded so far
+	ListConversations(ctx context.Context) ([]*store.Conversation, error)
+
+	// ListConversationsPage fetches the page of conversations following
+	// cursor, or the first page if cursor is empty
+	ListConversationsPage(ctx context.Context, cursor string) (*ConversationPage, error)
+
+	// GetMessages refreshes the newest messages of a conversation and returns
+	// its whole loaded history, oldest first
+	GetMessages(ctx context.Context, conversationID string) ([]*store.Message, error)
+
+	// GetMessagesPage fetches the page of messages older than cursor, or the
+	// newest page if cursor is empty
+	GetMessagesPage(ctx context.Context, conversationID string, cursor string) (*MessagePage, error)
+
+	// ListContacts fetches the phone's contact list
+	ListContacts(ctx context.Context) ([]*store.Contact, error)
+
+	// CreateConversation opens the conversation with the given phone numbers,
+	// creating it if needed, and caches it. Several numbers make a group.
+	CreateConversation(ctx context.Context, numbers []string) (*store.Conversation, error)
+
+	// SendMessage sends a text message to a conversation, quoting the message
+	// replyToID if set. tmpID is the ID of the local echo, which the phone's
+	// copy of the message replaces. simID picks the SIM to send from, empty
+	// for the conversation's. Fails with ErrPhoneOffline while the phone
+	// isn't responding.
+	SendMessage(ctx context.Context, conversationID string, text string, tmpID string, replyToID string, simID string) error
+
+	// SendMedia uploads a file and sends it to a conversation with an
+	// optional caption from the SIM simID, or the conversation's if empty,
+	// reporting progress if progress is not nil. tmpID is the ID of the local
+	// echo, which the phone's copy replaces. Fails with ErrPhoneOffline while
+	// the phone isn't responding.
+	SendMedia(ctx context.Context, conversationID string, path string, caption string, tmpID string, simID string, progress func(UploadProgress)) error
+
+	// SendReaction adds, removes or switches the user's reaction on a message
+	// and updates the cached message
+	SendReaction(ctx context.Context, conversationID string, messageID string, emoji string, action ReactionAction) error
+
+	// DeleteMessage deletes a message on the phone and drops it from the cache
+	DeleteMessage(ctx context.Context, conversationID string, messageID string) error
+
+	// ArchiveConversation archives or unarchives a conversation on the phone
+	// and updates the cached conversation
+	ArchiveConversation(ctx context.Context, conversationID string, archived bool) error
+
+	// MuteConversation mutes or unmutes a conversation's notifications on the
+	// phone. How long a mute lasts is up to the caller.
+	MuteConversation(ctx context.Context, conversationID string, muted bool) error
+
+	// BlockConversation blocks a conversation on the phone, reporting it as
+	// spam if report is set, and moves the cached conversation to the spam &
+	// blocked folder
+	BlockConversation(ctx context.Context, conversationID string, report bool) error
+
+	// UnblockConversation unblocks a conversation on the phone and moves the
+	// cached conversation back to the inbox
+	UnblockConversation(ctx context.Context, conversationID string) error
+
+	// LoadBlockedConversations fetches the spam & blocked folder into the
+	// cache. The conversation list leaves it out until it is opened.
+	LoadBlockedConversations(ctx context.Context) error
+
+	// DownloadMedia downloads and decrypts a message's attachment into the
+	// local media cache and returns its path
+	DownloadMedia(ctx context.Context, msg *store.Message) (string, error)
+
+	// SetTyping notifies a conversation that the user is typing. Notifications
+	// expire on their own, so callers resend them while typing continues.
+	SetTyping(ctx context.Context, conversationID string) error
+
+	// MarkRead marks a conversation as read
+	MarkRead(ctx context.Context, conversationID string, messageID string) error
+
+	// Close closes the backend and cleans up resources
+	Close()
+}
+
+// NewTmpID returns a temporary ID for a message that is about to be sent
+func NewTmpID() string {
+	return util.GenerateTmpID()
+}
+
+// ConversationPage is one page of the conversation list
+type ConversationPage struct {
+	// Conversations are the conversations first seen on this page
+	Conversations []*store.Conversation
+	// NextCursor fetches the following page; empty when there are no more
+	NextCursor string
+}
+
+// MessagePage is one page of a conversation's message history
+type MessagePage struct {
+	// Messages are the messages on this page, oldest first
+	Messages []*store.Message
+	// NextCursor fetches the next older page; empty at the beginning of the conversation
+	NextCursor string
+}
+
+// Ensure every backend implements Messenger
+var (
+	_ Messenger = (*Client)(nil)
+	_ Messenger = (*Fake)(nil)
+	_ Messenger = (*Accounts)(nil)
+)
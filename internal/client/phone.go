@@ -0,0 +1,66 @@
+package client
+
+import (
+	"errors"
+
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/gmproto"
+)
+
+// ErrPhoneOffline is returned by sends while the phone isn't responding,
+// instead of waiting for it
+var ErrPhoneOffline = errors.New("phone not responding")
+
+// Networks the phone can be on, as reported in PhoneStatus
+const (
+	NetworkWiFi = "wifi"
+	NetworkData = "data"
+)
+
+// PhoneStatus is the Data of EventTypePhoneStatus events
+type PhoneStatus struct {
+	// Offline is true while the phone doesn't answer pings
+	Offline bool
+	// BatteryLow is true after the phone says its battery is low, until it
+	// says it was restored
+	BatteryLow bool
+	// Network is NetworkWiFi or NetworkData, or empty until the phone says
+	Network string
+	// Account is the account the phone is paired in when Accounts runs
+	// several, empty otherwise
+	Account string
+}
+
+// phoneOffline reports whether the phone has stopped answering
+func (c *Client) phoneOffline() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.phone.Offline
+}
+
+// updatePhone changes the phone status and tells the UI, if anything changed
+func (c *Client) updatePhone(change func(*PhoneStatus)) {
+	c.mu.Lock()
+	status := c.phone
+	change(&status)
+	changed := status != c.phone
+	c.phone = status
+	c.mu.Unlock()
+
+	if changed {
+		c.eventChan <- Event{Type: EventTypePhoneStatus, Data: &status}
+	}
+}
+
+// handleUserAlert takes the battery and network state from a phone alert
+func (c *Client) handleUserAlert(alert *gmproto.UserAlertEvent) {
+	switch alert.GetAlertType() {
+	case gmproto.AlertType_MOBILE_BATTERY_LOW:
+		c.updatePhone(func(s *PhoneStatus) { s.BatteryLow = true })
+	case gmproto.AlertType_MOBILE_BATTERY_RESTORED:
+		c.updatePhone(func(s *PhoneStatus) { s.BatteryLow = false })
+	case gmproto.AlertType_MOBILE_WIFI_CONNECTION:
+		c.updatePhone(func(s *PhoneStatus) { s.Network = NetworkWiFi })
+	case gmproto.AlertType_MOBILE_DATA_CONNECTION:
+		c.updatePhone(func(s *PhoneStatus) { s.Network = NetworkData })
+	}
+}
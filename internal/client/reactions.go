@@ -0,0 +1,67 @@
+package client
+
+import (
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/gmproto"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// ReactionAction is what SendReaction does with an emoji
+type ReactionAction int
+
+const (
+	ReactionAdd    ReactionAction = iota // React to a message that has no reaction from the user
+	ReactionRemove                       // Take back the user's reaction
+	ReactionSwitch                       // Replace the user's reaction with another emoji
+)
+
+// ReactionActionFor returns the action that reacting to msg with emoji
+// performs: choosing the current reaction again removes it
+func ReactionActionFor(msg *store.Message, emoji string) ReactionAction {
+	switch msg.MyReaction() {
+	case "":
+		return ReactionAdd
+	case emoji:
+		return ReactionRemove
+	default:
+		return ReactionSwitch
+	}
+}
+
+// protoAction maps a ReactionAction to the libgm request action
+func (a ReactionAction) protoAction() gmproto.SendReactionRequest_Action {
+	switch a {
+	case ReactionRemove:
+		return gmproto.SendReactionRequest_REMOVE
+	case ReactionSwitch:
+		return gmproto.SendReactionRequest_SWITCH
+	default:
+		return gmproto.SendReactionRequest_ADD
+	}
+}
+
+// convertReactions converts libgm reaction entries, leaving sender names to
+// be filled in by the store from the conversation's participants
+func convertReactions(entries []*gmproto.ReactionEntry) []store.Reaction {
+	reactions := make([]store.Reaction, 0, len(entries))
+	for _, entry := range entries {
+		var emoji string
+		switch entry.GetData().GetType() {
+		case gmproto.EmojiType_EMOTIFY:
+			// Animated reactions have no text form
+			continue
+		case gmproto.EmojiType_CUSTOM:
+			emoji = entry.GetData().GetUnicode()
+		default:
+			emoji = entry.GetData().GetType().Unicode()
+		}
+		if emoji == "" || len(entry.GetParticipantIDs()) == 0 {
+			continue
+		}
+		reactions = append(reactions, store.Reaction{
+			Emoji:     emoji,
+			SenderIDs: entry.GetParticipantIDs(),
+		})
+	}
+	return reactions
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"time"
+
+	"go.mau.fi/mautrix-gmessages/pkg/libgm"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// sessionCheckInterval is how often the auth data is checked for changes
+// libgm makes without telling, like new cookies, and for a token running out
+const sessionCheckInterval = 5 * time.Minute
+
+// sessionExpiryWarning is how close to expiring the auth token has to get
+// before the user is warned. libgm refreshes it an hour ahead, so a token
+// this close means the refreshes have been failing.
+const sessionExpiryWarning = 30 * time.Minute
+
+// SessionWarning is the Data of EventTypeSessionExpiring events
+type SessionWarning struct {
+	// ExpiresAt is when the auth token runs out unless libgm refreshes it
+	ExpiresAt time.Time
+	// Account is the account whose session it is when Accounts runs
+	// several, empty otherwise
+	Account string
+}
+
+// marshalAuthData encodes libgm auth data for the session file
+func marshalAuthData(authData *libgm.AuthData) ([]byte, error) {
+	// libgm updates the cookies from every response
+	authData.CookiesLock.RLock()
+	defer authData.CookiesLock.RUnlock()
+	return json.Marshal(authData)
+}
+
+// watchSession saves the auth data whenever it changes and warns before the
+// auth token expires, until Close
+func (c *Client) watchSession() {
+	ticker := time.NewTicker(sessionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.saveAuthData()
+			c.checkExpiry()
+		}
+	}
+}
+
+// saveAuthData writes libgm's auth data to the session file if it changed
+// since it was last written
+func (c *Client) saveAuthData() {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+	if client == nil || client.AuthData == nil {
+		return
+	}
+
+	data, err := marshalAuthData(client.AuthData)
+	if err != nil {
+		log.Printf("Client: failed to encode auth data: %v", err)
+		return
+	}
+
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	if bytes.Equal(data, c.savedAuth) {
+		return
+	}
+	var session store.Session
+	if saved := c.store.GetSession(); saved != nil {
+		session = *saved
+	}
+	session.DevicePair = data
+	if err := c.store.SaveSession(&session); err != nil {
+		// The old token may still be refreshable, try again next time
+		log.Printf("Client: failed to save session: %v", err)
+		return
+	}
+	c.savedAuth = data
+	log.Printf("Client: saved updated auth data")
+}
+
+// checkExpiry warns once when the auth token is about to run out
+func (c *Client) checkExpiry() {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+	if client == nil || client.AuthData == nil {
+		return
+	}
+
+	expiry := client.AuthData.TachyonExpiry
+	if expiry.IsZero() || time.Until(expiry) > sessionExpiryWarning {
+		return
+	}
+
+	c.sessionMu.Lock()
+	warned := c.expiryWarned
+	c.expiryWarned = true
+	c.sessionMu.Unlock()
+
+	if !warned {
+		log.Printf("Client: auth token expires at %s and hasn't been refreshed", expiry.Format(time.RFC3339))
+		c.eventChan <- Event{Type: EventTypeSessionExpiring, Data: &SessionWarning{ExpiresAt: expiry}}
+	}
+}
+
+// handleTokenRefreshed saves a refreshed auth token and takes back any
+// expiry warning
+func (c *Client) handleTokenRefreshed() {
+	c.saveAuthData()
+
+	c.sessionMu.Lock()
+	warned := c.expiryWarned
+	c.expiryWarned = false
+	c.sessionMu.Unlock()
+
+	if warned {
+		c.eventChan <- Event{Type: EventTypeSessionRefreshed}
+	}
+}
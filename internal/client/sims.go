@@ -0,0 +1,89 @@
+package client
+
+import (
+	"sort"
+	"strings"
+
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/gmproto"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// handleSettings takes the list of SIMs from the phone's settings
+func (c *Client) handleSettings(settings *gmproto.Settings) {
+	// Some settings updates leave the SIMs out
+	if settings.GetSIMCards() == nil {
+		return
+	}
+
+	cards := make([]*gmproto.SIMCard, 0, len(settings.GetSIMCards()))
+	for _, card := range settings.GetSIMCards() {
+		if card.GetSIMParticipant().GetID() != "" {
+			cards = append(cards, card)
+		}
+	}
+	sort.SliceStable(cards, func(i, j int) bool {
+		return cards[i].GetSIMData().GetSIMPayload().GetSIMNumber() < cards[j].GetSIMData().GetSIMPayload().GetSIMNumber()
+	})
+
+	sims := make([]store.SIM, len(cards))
+	payloads := make(map[string]*gmproto.SIMPayload, len(cards))
+	for i, card := range cards {
+		id := card.GetSIMParticipant().GetID()
+		sims[i] = convertSIM(card, i+1)
+		payloads[id] = card.GetSIMData().GetSIMPayload()
+	}
+
+	c.mu.Lock()
+	c.simPayloads = payloads
+	c.mu.Unlock()
+
+	c.store.SetSIMs(sims)
+	c.eventChan <- Event{Type: EventTypeSIMsUpdated}
+}
+
+// convertSIM converts a libgm SIM card in the given slot to our store format
+func convertSIM(card *gmproto.SIMCard, slot int) store.SIM {
+	data := card.GetSIMData()
+	color := data.GetColorHex()
+	if color != "" && !strings.HasPrefix(color, "#") {
+		color = "#" + color
+	}
+	return store.SIM{
+		ParticipantID:   card.GetSIMParticipant().GetID(),
+		Slot:            slot,
+		Carrier:         data.GetCarrierName(),
+		FormattedNumber: data.GetFormattedPhoneNumber(),
+		Color:           color,
+	}
+}
+
+// simPayload returns what tells the phone to use a SIM, or nil to leave the
+// choice to the phone
+func (c *Client) simPayload(simID string) *gmproto.SIMPayload {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.simPayloads[simID]
+}
+
+// conversationSIM returns the SIM a conversation sends from
+func (c *Client) conversationSIM(conversationID string) string {
+	if conv := c.store.GetConversation(conversationID); conv != nil {
+		return conv.SendingSIM()
+	}
+	return ""
+}
+
+// setSIM makes a message go out on the given SIM, or the conversation's if
+// simID is empty. The phone picks if it hasn't listed the SIM.
+func (c *Client) setSIM(req *gmproto.SendMessageRequest, simID string) {
+	if simID == "" {
+		simID = c.conversationSIM(req.GetConversationID())
+	}
+	payload := c.simPayload(simID)
+	if payload == nil {
+		return
+	}
+	req.SIMPayload = payload
+	req.MessagePayload.ParticipantID = simID
+}
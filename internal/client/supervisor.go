@@ -0,0 +1,189 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/events"
+)
+
+// ErrSessionExpired is reported when the phone or Google rejects our credentials
+// and reconnecting cannot help
+var ErrSessionExpired = errors.New("session expired, run with -clear-session to pair again")
+
+// ReconnectInfo is the Data of EventTypeReconnecting events
+type ReconnectInfo struct {
+	// Attempt is the 1-based reconnect attempt number (0 for temporary errors)
+	Attempt int
+	// RetryAt is when the next attempt will be made (zero if libgm retries on its own)
+	RetryAt time.Time
+	// Temporary is true if libgm is retrying by itself after a temporary error
+	Temporary bool
+}
+
+// Backoff computes jittered exponential retry delays
+type Backoff struct {
+	Min    time.Duration
+	Max    time.Duration
+	Factor float64
+
+	attempt int
+}
+
+// Next returns the delay before the next attempt. Each delay lies between half
+// and all of the exponential step so that many clients don't retry in lockstep.
+func (b *Backoff) Next() time.Duration {
+	d := float64(b.Min)
+	for i := 0; i < b.attempt && d < float64(b.Max); i++ {
+		d *= b.Factor
+	}
+	if d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	b.attempt++
+
+	half := d / 2
+	return time.Duration(half + rand.Float64()*half)
+}
+
+// Reset starts the delay sequence over
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
+
+// supervisor reconnects the libgm client after fatal listen errors
+type supervisor struct {
+	c       *Client
+	backoff Backoff
+
+	mu       sync.Mutex
+	running  bool
+	stopped  bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup // the running reconnect loop
+}
+
+// newSupervisor creates a supervisor for c
+func newSupervisor(c *Client) *supervisor {
+	return &supervisor{
+		c: c,
+		backoff: Backoff{
+			Min:    2 * time.Second,
+			Max:    5 * time.Minute,
+			Factor: 2,
+		},
+		stop: make(chan struct{}),
+	}
+}
+
+// trigger starts a reconnect loop unless one is already running
+func (s *supervisor) trigger(cause error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running || s.stopped {
+		return
+	}
+	s.running = true
+	s.wg.Add(1)
+	go s.run(cause)
+}
+
+// run retries until reconnected, stopped, or the session is rejected
+func (s *supervisor) run(cause error) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	s.backoff.Reset()
+	for attempt := 1; ; attempt++ {
+		delay := s.backoff.Next()
+		log.Printf("Supervisor: reconnect attempt %d in %s after: %v", attempt, delay.Round(time.Second), cause)
+		if !s.emit(Event{
+			Type:  EventTypeReconnecting,
+			Error: cause,
+			Data: &ReconnectInfo{
+				Attempt: attempt,
+				RetryAt: time.Now().Add(delay),
+			},
+		}) {
+			return
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(delay):
+		}
+
+		err := s.c.reconnect()
+		if err == nil {
+			log.Printf("Supervisor: reconnected after %d attempt(s)", attempt)
+			s.emit(Event{Type: EventTypeReconnected})
+			return
+		}
+
+		if isAuthError(err) {
+			log.Printf("Supervisor: giving up, credentials rejected: %v", err)
+			s.emit(Event{
+				Type:  EventTypeError,
+				Error: fmt.Errorf("%w: %v", ErrSessionExpired, err),
+			})
+			return
+		}
+		cause = err
+	}
+}
+
+// emit sends an event unless the supervisor is stopped, and reports whether
+// it was sent
+func (s *supervisor) emit(evt Event) bool {
+	select {
+	case s.c.eventChan <- evt:
+		return true
+	case <-s.stop:
+		return false
+	}
+}
+
+// Stop stops any running reconnect loop and waits for it to exit, so the
+// event channel can be closed afterwards
+func (s *supervisor) Stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	s.wg.Wait()
+}
+
+// isAuthError reports whether err means our credentials were rejected, as
+// opposed to a network or server problem that may go away on its own
+func isAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, events.ErrInvalidCredentials) || errors.Is(err, events.ErrCallerNoPermission) {
+		return true
+	}
+
+	var httpErr events.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Resp != nil {
+		code := httpErr.Resp.StatusCode
+		return code == http.StatusUnauthorized || code == http.StatusForbidden
+	}
+
+	return false
+}
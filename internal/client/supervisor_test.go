@@ -0,0 +1,58 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/events"
+)
+
+func TestBackoff(t *testing.T) {
+	b := &Backoff{Min: time.Second, Max: 30 * time.Second, Factor: 2}
+
+	// Each delay lies between half and all of its exponential step
+	steps := []time.Duration{1, 2, 4, 8, 16, 30, 30, 30}
+	for run := 0; run < 2; run++ {
+		for i, step := range steps {
+			step *= time.Second
+			if d := b.Next(); d < step/2 || d > step {
+				t.Errorf("run %d, attempt %d: delay %v outside [%v, %v]", run, i+1, d, step/2, step)
+			}
+		}
+		b.Reset()
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	httpError := func(code int) error {
+		return events.HTTPError{Resp: &http.Response{StatusCode: code}}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "network error", err: errors.New("connection reset by peer"), want: false},
+		{name: "invalid credentials", err: events.ErrInvalidCredentials, want: true},
+		{name: "wrapped invalid credentials", err: fmt.Errorf("failed to connect: %w", events.ErrInvalidCredentials), want: true},
+		{name: "no permission", err: events.ErrCallerNoPermission, want: true},
+		{name: "HTTP 401", err: httpError(http.StatusUnauthorized), want: true},
+		{name: "wrapped HTTP 403", err: fmt.Errorf("failed to reconnect: %w", httpError(http.StatusForbidden)), want: true},
+		{name: "HTTP 500", err: httpError(http.StatusInternalServerError), want: false},
+		{name: "HTTP 429", err: httpError(http.StatusTooManyRequests), want: false},
+		{name: "HTTP error without response", err: events.HTTPError{}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAuthError(tt.err); got != tt.want {
+				t.Errorf("isAuthError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
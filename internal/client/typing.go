@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/gmproto"
+)
+
+// TypingInfo is the Data of an EventTypeTypingIndicator event
+type TypingInfo struct {
+	ConversationID string
+	Name           string // who is typing
+	Typing         bool   // false once they stopped
+}
+
+// handleTyping turns a libgm typing update into an EventTypeTypingIndicator event
+func (c *Client) handleTyping(data *gmproto.TypingData) {
+	number := data.GetUser().GetNumber()
+	name := number
+
+	conv := c.store.GetConversation(data.GetConversationID())
+	if conv != nil {
+		for _, p := range conv.Participants {
+			if p.Number != "" && p.Number == number {
+				if p.IsMe {
+					return
+				}
+				name = p.DisplayName()
+				break
+			}
+		}
+	}
+	if contact := c.store.ContactName(number); contact != "" {
+		name = contact
+	}
+
+	c.eventChan <- Event{
+		Type: EventTypeTypingIndicator,
+		Data: &TypingInfo{
+			ConversationID: data.GetConversationID(),
+			Name:           name,
+			Typing:         data.GetType() == gmproto.TypingTypes_STARTED_TYPING,
+		},
+	}
+}
+
+// SetTyping tells the other participants of a conversation that the user is typing
+func (c *Client) SetTyping(ctx context.Context, conversationID string) error {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return fmt.Errorf("client not connected")
+	}
+
+	if err := client.SetTyping(conversationID, c.simPayload(c.conversationSIM(conversationID))); err != nil {
+		return fmt.Errorf("failed to send typing notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/gmproto"
+	"go.mau.fi/mautrix-gmessages/pkg/libgm/util"
+)
+
+// Upload stages reported through UploadProgress
+const (
+	UploadStageReading   = "reading"
+	UploadStageUploading = "uploading"
+	UploadStageSending   = "sending"
+)
+
+// uploadSteps is the number of stages an attachment goes through
+const uploadSteps = 3
+
+// MaxAttachmentSize is the largest file Google Messages accepts as an attachment
+const MaxAttachmentSize = 100 << 20
+
+// readChunk is how much of an attachment is read between progress reports
+const readChunk = 1 << 20
+
+// UploadProgress describes how far an attachment upload has got
+type UploadProgress struct {
+	FileName string
+	Size     int64
+	Read     int64 // bytes read so far while reading
+	Stage    string
+	Step     int // 1-based index of Stage
+	Steps    int
+}
+
+// StatAttachment returns the info of a file to send as an attachment, or an
+// error if it can't be sent
+func StatAttachment(path string) (os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("failed to read attachment: %s is a directory", path)
+	}
+	if info.Size() > MaxAttachmentSize {
+		return nil, fmt.Errorf("%s is too large to send, attachments can be at most %d MB", filepath.Base(path), MaxAttachmentSize>>20)
+	}
+	return info, nil
+}
+
+// readAttachment reads a file to upload and determines its MIME type, calling
+// progress, if not nil, with the bytes read so far and the file's size
+func readAttachment(path string, progress func(read, size int64)) (data []byte, mimeType string, err error) {
+	info, err := StatAttachment(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read attachment: %w", err)
+	}
+	defer f.Close()
+
+	data = make([]byte, info.Size())
+	for read := 0; read < len(data); {
+		n, err := io.ReadFull(f, data[read:min(read+readChunk, len(data))])
+		read += n
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read attachment: %w", err)
+		}
+		if progress != nil {
+			progress(int64(read), int64(len(data)))
+		}
+	}
+
+	mimeType = mime.TypeByExtension(filepath.Ext(path))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	// Drop parameters such as "; charset=utf-8"
+	if parsed, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = parsed
+	}
+
+	return data, mimeType, nil
+}
+
+// SendMedia uploads a file and sends it to a conversation with an optional
+// caption, from the SIM simID or the conversation's if empty. The phone's copy
+// replaces the local echo tmpID, if given. progress, if not nil, is called as
+// the upload moves through stages.
+//
+// libgm encrypts and posts the whole file in a single request without saying
+// how far it got, so only reading the file reports bytes.
+func (c *Client) SendMedia(ctx context.Context, conversationID string, path string, caption string, tmpID string, simID string, progress func(UploadProgress)) error {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return fmt.Errorf("client not connected")
+	}
+	if c.phoneOffline() {
+		return ErrPhoneOffline
+	}
+
+	fileName := filepath.Base(path)
+	var size, read int64
+	report := func(stage string, step int) {
+		if progress != nil {
+			progress(UploadProgress{FileName: fileName, Size: size, Read: read, Stage: stage, Step: step, Steps: uploadSteps})
+		}
+	}
+
+	report(UploadStageReading, 1)
+	data, mimeType, err := readAttachment(path, func(n, total int64) {
+		read, size = n, total
+		report(UploadStageReading, 1)
+	})
+	if err != nil {
+		return err
+	}
+
+	report(UploadStageUploading, 2)
+	media, err := client.UploadMedia(data, fileName, mimeType)
+	if err != nil {
+		return fmt.Errorf("failed to upload attachment: %w", err)
+	}
+
+	report(UploadStageSending, 3)
+	infos := []*gmproto.MessageInfo{{
+		Data: &gmproto.MessageInfo_MediaContent{MediaContent: media},
+	}}
+	if caption != "" {
+		infos = append(infos, &gmproto.MessageInfo{
+			Data: &gmproto.MessageInfo_MessageContent{MessageContent: &gmproto.MessageContent{
+				Content: caption,
+			}},
+		})
+	}
+
+	if tmpID == "" {
+		tmpID = util.GenerateTmpID()
+	}
+	req := &gmproto.SendMessageRequest{
+		ConversationID: conversationID,
+		MessagePayload: &gmproto.MessagePayload{
+			TmpID:          tmpID,
+			TmpID2:         tmpID,
+			ConversationID: conversationID,
+			MessageInfo:    infos,
+		},
+		TmpID: tmpID,
+	}
+	c.setSIM(req, simID)
+
+	resp, err := client.SendMessage(req)
+	if err != nil {
+		return fmt.Errorf("failed to send attachment: %w", err)
+	}
+	if resp.GetStatus() != gmproto.SendMessageResponse_SUCCESS {
+		return fmt.Errorf("failed to send attachment: %s", resp.GetStatus())
+	}
+
+	return nil
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 
 	"gopkg.in/yaml.v3"
 )
@@ -17,6 +19,24 @@ type Config struct {
 	Theme ThemeConfig `yaml:"theme"`
 	// Keybinds settings
 	Keybinds KeybindConfig `yaml:"keybinds"`
+	// Privacy settings
+	Privacy PrivacyConfig `yaml:"privacy"`
+	// Notification settings
+	Notifications NotificationConfig `yaml:"notifications"`
+}
+
+// NotificationConfig holds notification-related settings
+type NotificationConfig struct {
+	// Bell rings the terminal bell when a message arrives in a conversation
+	// that isn't open or muted (default: true)
+	Bell bool `yaml:"bell"`
+}
+
+// PrivacyConfig holds privacy-related settings
+type PrivacyConfig struct {
+	// ReadReceipts marks conversations read on the phone when they are opened
+	// or scrolled to the newest message (default: true)
+	ReadReceipts bool `yaml:"read_receipts"`
 }
 
 // KeybindConfig holds keybind-related settings
@@ -68,6 +88,12 @@ func DefaultConfig() *Config {
 			SecondaryColor: "#10B981",
 		},
 		Keybinds: DefaultKeybinds(),
+		Privacy: PrivacyConfig{
+			ReadReceipts: true,
+		},
+		Notifications: NotificationConfig{
+			Bell: true,
+		},
 	}
 }
 
@@ -90,8 +116,36 @@ func DefaultKeybinds() KeybindConfig {
 	}
 }
 
-// ConfigDir returns the path to the config directory
-func ConfigDir() (string, error) {
+// DefaultProfile is the profile used when none is chosen. Its files live
+// in BaseDir itself, where they were before there were profiles.
+const DefaultProfile = "default"
+
+// profile is the profile ConfigDir points to, see SetProfile
+var profile = DefaultProfile
+
+// profileName matches the names a profile can be given
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateProfile checks that a profile name can be used as a directory name
+func ValidateProfile(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// SetProfile makes ConfigDir, Load and Save use a profile's files
+func SetProfile(name string) {
+	profile = name
+}
+
+// Profile returns the profile set with SetProfile
+func Profile() string {
+	return profile
+}
+
+// BaseDir returns the path to the directory holding every profile
+func BaseDir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -99,7 +153,25 @@ func ConfigDir() (string, error) {
 	return filepath.Join(home, ".config", "messages-tui"), nil
 }
 
-// ConfigPath returns the path to the config file
+// ProfileDir returns the path to the directory holding a profile's session,
+// caches and config overrides
+func ProfileDir(name string) (string, error) {
+	dir, err := BaseDir()
+	if err != nil {
+		return "", err
+	}
+	if name == "" || name == DefaultProfile {
+		return dir, nil
+	}
+	return filepath.Join(dir, "profiles", name), nil
+}
+
+// ConfigDir returns the path to the config directory of the current profile
+func ConfigDir() (string, error) {
+	return ProfileDir(profile)
+}
+
+// ConfigPath returns the path to the config file of the current profile
 func ConfigPath() (string, error) {
 	dir, err := ConfigDir()
 	if err != nil {
@@ -108,25 +180,33 @@ func ConfigPath() (string, error) {
 	return filepath.Join(dir, "config.yaml"), nil
 }
 
-// Load loads the configuration from disk, or returns defaults if not found
+// Load loads the configuration from disk, or returns defaults if not found.
+// A profile's config.yaml overrides the settings it sets in the default
+// profile's.
 func Load() (*Config, error) {
 	cfg := DefaultConfig()
 
-	path, err := ConfigPath()
+	paths := make([]string, 0, 2)
+	base, err := ProfileDir(DefaultProfile)
 	if err != nil {
 		return cfg, nil
 	}
-
-	data, err := os.ReadFile(path)
-	if os.IsNotExist(err) {
-		return cfg, nil
-	}
-	if err != nil {
-		return nil, err
+	paths = append(paths, filepath.Join(base, "config.yaml"))
+	if path, err := ConfigPath(); err == nil && path != paths[0] {
+		paths = append(paths, path)
 	}
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
-		return nil, err
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
 	}
 
 	// Ensure editor is set even if config file has empty value
@@ -0,0 +1,268 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// contactsPath returns the path to the contacts file
+func (s *Store) contactsPath() (string, error) {
+	dir, err := s.dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "contacts.json"), nil
+}
+
+// LoadContacts restores the contact list saved by the last sync, so names
+// show up before the phone answers
+func (s *Store) LoadContacts() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.contactsPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := s.readSealed(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var contacts []*Contact
+	if err := json.Unmarshal(data, &contacts); err != nil {
+		return err
+	}
+	s.setContacts(contacts)
+	return nil
+}
+
+// SetContacts replaces the contact list with a fresh copy from the phone,
+// renames the cached conversations and messages after it and saves it
+func (s *Store) SetContacts(contacts []*Contact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setContacts(contacts)
+	s.resolveCached()
+
+	path, err := s.contactsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(contacts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.writeSealed(path, data)
+}
+
+// setContacts indexes contacts by phone number.
+// Must be called with mutex held.
+func (s *Store) setContacts(contacts []*Contact) {
+	s.contacts = make(map[string]*Contact, len(contacts))
+	for _, c := range contacts {
+		if key := numberKey(c.Number); key != "" {
+			s.contacts[key] = c
+		}
+	}
+}
+
+// GetContacts returns all contacts sorted by name
+func (s *Store) GetContacts() []*Contact {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	contacts := make([]*Contact, 0, len(s.contacts))
+	for _, c := range s.contacts {
+		contacts = append(contacts, c)
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		return strings.ToLower(contacts[i].Name) < strings.ToLower(contacts[j].Name)
+	})
+	return contacts
+}
+
+// ContactName returns the name saved on the phone for a number, or "" if
+// the number is not a contact
+func (s *Store) ContactName(number string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.contactName(number)
+}
+
+// contactName looks up a number in the contact list.
+// Must be called with mutex held.
+func (s *Store) contactName(number string) string {
+	if c, ok := s.contacts[numberKey(number)]; ok {
+		return c.Name
+	}
+	return ""
+}
+
+// ResolveConversation names a freshly converted conversation and its
+// participants after their contacts. One-to-one threads take the contact's
+// name, groups without a name of their own list their members.
+func (s *Store) ResolveConversation(conv *Conversation) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.resolveConversation(conv)
+}
+
+// resolveConversation is ResolveConversation with the mutex held. The
+// participants are copied before renaming, so conv may share them.
+func (s *Store) resolveConversation(conv *Conversation) bool {
+	changed := false
+	for i, p := range conv.Participants {
+		name := s.contactName(p.Number)
+		if p.IsMe || name == "" || name == p.Name {
+			continue
+		}
+		if !changed {
+			conv.Participants = append([]Participant(nil), conv.Participants...)
+			changed = true
+		}
+		conv.Participants[i].Name = name
+	}
+
+	others := conv.Others()
+	var name string
+	switch {
+	case !conv.IsGroup && len(others) == 1:
+		name = s.contactName(others[0].Number)
+	case conv.IsGroup && (conv.Name == "" || isPhoneNumber(conv.Name)):
+		names := make([]string, len(others))
+		for i, p := range others {
+			names[i] = p.DisplayName()
+		}
+		name = strings.Join(names, ", ")
+	}
+	if name == "" || name == conv.Name {
+		return changed
+	}
+	conv.Name = name
+	return true
+}
+
+// ResolveMessage names the sender of a freshly converted message and everyone
+// who reacted to it, from the participants of its conversation and the
+// contacts
+func (s *Store) ResolveMessage(msg *Message) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.resolveMessage(msg)
+}
+
+// resolveMessage is ResolveMessage with the mutex held. The reactions are
+// copied before renaming, so msg may share them.
+func (s *Store) resolveMessage(msg *Message) bool {
+	conv := s.conversations[msg.ConversationID]
+	changed := s.resolveReactions(msg, conv)
+	if msg.IsFromMe {
+		return changed
+	}
+
+	name := ""
+	if p := conv.Participant(msg.SenderID); p != nil {
+		name = p.DisplayName()
+		if msg.SenderNumber == "" && p.Number != "" {
+			msg.SenderNumber = p.Number
+			changed = true
+		}
+	}
+	if contact := s.contactName(msg.SenderNumber); contact != "" {
+		name = contact
+	}
+	if name == "" || name == msg.SenderName {
+		return changed
+	}
+	msg.SenderName = name
+	return true
+}
+
+// resolveReactions fills in who sent each reaction of msg from the
+// participants of conv. Unknown senders are shown by ID.
+// Must be called with mutex held.
+func (s *Store) resolveReactions(msg *Message, conv *Conversation) bool {
+	if len(msg.Reactions) == 0 {
+		return false
+	}
+
+	reactions := make([]Reaction, len(msg.Reactions))
+	changed := false
+	for i, r := range msg.Reactions {
+		// Reactions added here have no sender IDs until the phone echoes
+		// them, so FromMe is only ever set here
+		resolved := Reaction{Emoji: r.Emoji, SenderIDs: r.SenderIDs, FromMe: r.FromMe}
+		for _, id := range r.SenderIDs {
+			switch p := conv.Participant(id); {
+			case p != nil && p.IsMe:
+				resolved.FromMe = true
+			case p != nil:
+				resolved.Senders = append(resolved.Senders, p.DisplayName())
+			default:
+				resolved.Senders = append(resolved.Senders, id)
+			}
+		}
+		if (conv == nil || len(conv.Participants) == 0) && len(r.Senders) > 0 {
+			// Nothing to go by, keep the names it came with
+			resolved.Senders = r.Senders
+		}
+		if resolved.FromMe != r.FromMe || !slices.Equal(resolved.Senders, r.Senders) {
+			changed = true
+		}
+		reactions[i] = resolved
+	}
+	if changed {
+		msg.Reactions = reactions
+	}
+	return changed
+}
+
+// resolveCached renames cached conversations and messages after the
+// contacts, replacing the ones that change with copies.
+// Must be called with mutex held.
+func (s *Store) resolveCached() {
+	for id, conv := range s.conversations {
+		c := *conv
+		if s.resolveConversation(&c) {
+			s.conversations[id] = &c
+		}
+	}
+	for _, msgs := range s.messages {
+		for i, msg := range msgs {
+			m := *msg
+			if s.resolveMessage(&m) {
+				msgs[i] = &m
+			}
+		}
+	}
+}
+
+// numberKey reduces a phone number to its last ten digits, so numbers match
+// with or without country code and formatting
+func numberKey(number string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+	return digits
+}
+
+// isPhoneNumber returns whether s is a phone number rather than a name
+func isPhoneNumber(s string) bool {
+	return numberKey(s) != "" && strings.Trim(s, "+0123456789 -().") == ""
+}
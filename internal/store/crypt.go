@@ -0,0 +1,225 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ErrLocked is returned when reading an encrypted file without a secret
+var ErrLocked = errors.New("the session is encrypted, a passphrase or key is needed")
+
+// ErrWrongKey is returned when an encrypted file can't be opened with the secret
+var ErrWrongKey = errors.New("wrong passphrase or key")
+
+// Argon2id parameters for new files. Opening a file uses the ones it was
+// written with.
+const (
+	kdfTime    = 3
+	kdfMemory  = 64 * 1024 // KiB
+	kdfThreads = 4
+	kdfKeySize = 32 // AES-256
+	saltSize   = 16
+)
+
+// sealedFile is the on-disk form of an encrypted file
+type sealedFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Time       uint32 `json:"time"`
+	Memory     uint32 `json:"memory"`
+	Threads    uint8  `json:"threads"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// SetSecret sets the passphrase or key that the store's files are encrypted
+// with. Nil writes them in the clear.
+func (s *Store) SetSecret(secret []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setSecret(secret)
+}
+
+// ShareSecret encrypts the store's files with the secret of another store,
+// for a store without a session of its own
+func (s *Store) ShareSecret(other *Store) {
+	other.mu.RLock()
+	secret := other.secret
+	other.mu.RUnlock()
+	s.SetSecret(secret)
+}
+
+// setSecret forgets the keys derived from the old secret.
+// Must be called with mutex held.
+func (s *Store) setSecret(secret []byte) {
+	s.secret = secret
+	s.salt = nil
+	s.keys = make(map[string][]byte)
+}
+
+// deriveKey derives the file key for a salt from the secret, once per salt.
+// Must be called with mutex held.
+func (s *Store) deriveKey(salt []byte, time, memory uint32, threads uint8) []byte {
+	id := fmt.Sprintf("%x/%d/%d/%d", salt, time, memory, threads)
+	if key, ok := s.keys[id]; ok {
+		return key
+	}
+	key := argon2.IDKey(s.secret, salt, time, memory, threads, kdfKeySize)
+	s.keys[id] = key
+	return key
+}
+
+// seal encrypts data for writing if a secret is set, and returns it as is
+// otherwise. Files sealed with the same secret share a salt, so the key is
+// only derived once.
+// Must be called with mutex held.
+func (s *Store) seal(data []byte) ([]byte, error) {
+	if s.secret == nil {
+		return data, nil
+	}
+	if s.salt == nil {
+		s.salt = make([]byte, saltSize)
+		if _, err := rand.Read(s.salt); err != nil {
+			return nil, err
+		}
+	}
+
+	gcm, err := newGCM(s.deriveKey(s.salt, kdfTime, kdfMemory, kdfThreads))
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(sealedFile{
+		Version:    1,
+		KDF:        "argon2id",
+		Time:       kdfTime,
+		Memory:     kdfMemory,
+		Threads:    kdfThreads,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, data, nil),
+	}, "", "  ")
+}
+
+// open decrypts data read from a file, and reports whether it was encrypted.
+// Data that isn't is returned as is.
+// Must be called with mutex held.
+func (s *Store) open(data []byte) ([]byte, bool, error) {
+	var sealed sealedFile
+	if err := json.Unmarshal(data, &sealed); err != nil || sealed.Ciphertext == nil {
+		return data, false, nil
+	}
+	if sealed.Version != 1 || sealed.KDF != "argon2id" {
+		return nil, true, fmt.Errorf("unsupported encryption %s version %d", sealed.KDF, sealed.Version)
+	}
+	if s.secret == nil {
+		return nil, true, ErrLocked
+	}
+
+	gcm, err := newGCM(s.deriveKey(sealed.Salt, sealed.Time, sealed.Memory, sealed.Threads))
+	if err != nil {
+		return nil, true, err
+	}
+	if len(sealed.Nonce) != gcm.NonceSize() {
+		return nil, true, fmt.Errorf("invalid nonce")
+	}
+	plain, err := gcm.Open(nil, sealed.Nonce, sealed.Ciphertext, nil)
+	if err != nil {
+		return nil, true, ErrWrongKey
+	}
+
+	// Keep writing with the same key
+	s.salt = sealed.Salt
+	return plain, true, nil
+}
+
+// readSealed reads a file written by writeSealed. A file saved in the clear
+// is encrypted in place if a secret is set.
+// Must be called with mutex held.
+func (s *Store) readSealed(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, encrypted, err := s.open(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	if !encrypted && s.secret != nil {
+		if err := s.writeSealed(path, plain); err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w", filepath.Base(path), err)
+		}
+	}
+	return plain, nil
+}
+
+// writeSealed writes a file atomically, encrypted if a secret is set.
+// Must be called with mutex held.
+func (s *Store) writeSealed(path string, data []byte) error {
+	data, err := s.seal(data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+// sealedPaths returns every file of the store that writeSealed writes
+func (s *Store) sealedPaths() ([]string, error) {
+	var paths []string
+	for _, path := range []func() (string, error){s.outboxPath, s.schedulePath, s.contactsPath, s.prefsPath} {
+		p, err := path()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+
+	// The media index and the attachments
+	dir, err := s.mediaDir()
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() && !strings.HasPrefix(d.Name(), ".tmp-") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// isSealed reports whether file data is encrypted
+func isSealed(data []byte) bool {
+	var sealed sealedFile
+	return json.Unmarshal(data, &sealed) == nil && sealed.Ciphertext != nil
+}
+
+// newGCM creates an AES-GCM cipher with the key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSealOpen(t *testing.T) {
+	plain := []byte(`{"hello":"world"}`)
+
+	tests := []struct {
+		name          string
+		sealWith      []byte
+		openWith      []byte
+		wantEncrypted bool
+		wantErr       error
+	}{
+		{name: "clear text without a secret", wantEncrypted: false},
+		{name: "clear text read with a secret", openWith: []byte("secret"), wantEncrypted: false},
+		{name: "same secret", sealWith: []byte("secret"), openWith: []byte("secret"), wantEncrypted: true},
+		{name: "wrong secret", sealWith: []byte("secret"), openWith: []byte("other"), wantEncrypted: true, wantErr: ErrWrongKey},
+		{name: "no secret", sealWith: []byte("secret"), wantEncrypted: true, wantErr: ErrLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealer := New()
+			sealer.SetSecret(tt.sealWith)
+			data, err := sealer.seal(plain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := isSealed(data); got != (tt.sealWith != nil) {
+				t.Errorf("isSealed is %v", got)
+			}
+
+			opener := New()
+			opener.SetSecret(tt.openWith)
+			got, encrypted, err := opener.open(data)
+			if encrypted != tt.wantEncrypted {
+				t.Errorf("encrypted is %v, want %v", encrypted, tt.wantEncrypted)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !bytes.Equal(got, plain) {
+				t.Errorf("got %q, want %q", got, plain)
+			}
+		})
+	}
+}
+
+func TestRekey(t *testing.T) {
+	oldSecret, newSecret := []byte("old secret"), []byte("new secret")
+	t.Setenv("HOME", t.TempDir())
+
+	s := New()
+	s.SetSecret(oldSecret)
+	if err := s.SaveSession(&Session{DevicePair: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.QueueMessage(&Message{ID: "tmp_1", ConversationID: "c1", Content: "hi", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Rekey(newSecret); err != nil {
+		t.Fatalf("Rekey: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		secret  []byte
+		wantErr error
+	}{
+		{name: "new secret", secret: newSecret},
+		{name: "old secret", secret: oldSecret, wantErr: ErrWrongKey},
+		{name: "no secret", wantErr: ErrLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reopened := New()
+			reopened.SetSecret(tt.secret)
+
+			session, err := reopened.LoadSession()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadSession: got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && session == nil {
+				t.Fatal("LoadSession found no session")
+			}
+
+			err = reopened.LoadOutbox()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadOutbox: got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && len(reopened.GetMessages("c1")) != 1 {
+				t.Errorf("outbox holds %d messages, want 1", len(reopened.GetMessages("c1")))
+			}
+		})
+	}
+
+	// The store keeps writing with the new secret
+	if err := s.QueueMessage(&Message{ID: "tmp_2", ConversationID: "c1", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	reopened := New()
+	reopened.SetSecret(newSecret)
+	if err := reopened.LoadOutbox(); err != nil {
+		t.Fatalf("outbox written after Rekey: %v", err)
+	}
+}
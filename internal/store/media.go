@@ -0,0 +1,250 @@
+package store
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mediaDir returns the directory holding downloaded attachments
+func (s *Store) mediaDir() (string, error) {
+	dir, err := s.dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "media"), nil
+}
+
+// mediaIndexPath returns the path of the media ID to file index
+func (s *Store) mediaIndexPath() (string, error) {
+	dir, err := s.mediaDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "index.json"), nil
+}
+
+// loadMediaIndex reads the media index from disk once.
+// Must be called with mutex held.
+func (s *Store) loadMediaIndex() error {
+	if s.mediaIndex != nil {
+		return nil
+	}
+
+	path, err := s.mediaIndexPath()
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string]string)
+	data, err := s.readSealed(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			// A corrupt index only costs re-downloads, start over
+			index = make(map[string]string)
+		}
+	}
+
+	s.mediaIndex = index
+	return nil
+}
+
+// CachedMedia returns the local path of a downloaded attachment, if cached
+func (s *Store) CachedMedia(mediaID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadMediaIndex(); err != nil {
+		return "", false
+	}
+
+	name, ok := s.mediaIndex[mediaID]
+	if !ok {
+		return "", false
+	}
+
+	dir, err := s.mediaDir()
+	if err != nil {
+		return "", false
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	if s.secret == nil {
+		return path, true
+	}
+
+	revealed, err := s.revealedPath(name)
+	if err != nil {
+		return "", false
+	}
+	if _, err := os.Stat(revealed); err == nil {
+		return revealed, true
+	}
+	data, err := s.readSealed(path)
+	if err != nil {
+		return "", false
+	}
+	if err := os.WriteFile(revealed, data, 0600); err != nil {
+		return "", false
+	}
+	return revealed, true
+}
+
+// CacheMedia stores a decrypted attachment under the hash of its content,
+// see mediaHash, and returns its local path. Identical files are only stored once. If a secret
+// is set the stored file is encrypted, and the returned path is a decrypted
+// copy, see revealedPath.
+func (s *Store) CacheMedia(mediaID, fileName, mimeType string, data []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadMediaIndex(); err != nil {
+		return "", err
+	}
+
+	dir, err := s.mediaDir()
+	if err != nil {
+		return "", err
+	}
+
+	hash := s.mediaHash(data)
+	name := filepath.Join(hash[:2], hash+mediaExtension(fileName, mimeType))
+	path := filepath.Join(dir, name)
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := s.writeSealed(path, data); err != nil {
+			return "", err
+		}
+	}
+
+	s.mediaIndex[mediaID] = name
+	indexData, err := json.MarshalIndent(s.mediaIndex, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	indexPath, err := s.mediaIndexPath()
+	if err != nil {
+		return "", err
+	}
+	if err := s.writeSealed(indexPath, indexData); err != nil {
+		return "", err
+	}
+
+	if s.secret == nil {
+		return path, nil
+	}
+	revealed, err := s.revealedPath(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(revealed, data, 0600); err != nil {
+		return "", err
+	}
+	return revealed, nil
+}
+
+// revealedPath returns where the decrypted copy of an encrypted attachment
+// goes for viewers to open. The copies live in a private directory that
+// Close removes.
+// Must be called with mutex held.
+func (s *Store) revealedPath(name string) (string, error) {
+	if s.revealDir == "" {
+		dir, err := os.MkdirTemp(os.Getenv("XDG_RUNTIME_DIR"), "messages-tui-")
+		if err != nil {
+			return "", err
+		}
+		s.revealDir = dir
+	}
+	return filepath.Join(s.revealDir, filepath.Base(name)), nil
+}
+
+// Close removes the decrypted copies of attachments
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.revealDir == "" {
+		return nil
+	}
+	err := os.RemoveAll(s.revealDir)
+	s.revealDir = ""
+	return err
+}
+
+// mediaNameSalt is the salt of the key encrypted attachments are named with
+var mediaNameSalt = []byte("messages-tui media names")
+
+// mediaHash hashes an attachment's content to name it after. With a secret
+// the hash is an HMAC under a key derived from it, so the names on disk
+// don't give away what the encrypted files hold.
+// Must be called with mutex held.
+func (s *Store) mediaHash(data []byte) string {
+	if s.secret == nil {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, s.deriveKey(mediaNameSalt, kdfTime, kdfMemory, kdfThreads))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// mediaExtension picks a file extension from the original name or MIME type
+func mediaExtension(fileName, mimeType string) string {
+	if ext := filepath.Ext(fileName); ext != "" && !strings.ContainsAny(ext, `/\`) {
+		return strings.ToLower(ext)
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place
+// so readers never see a partially written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpPath, err := writeTemp(path, data, perm)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// writeTemp writes data to a temporary file next to path and returns its
+// path, for the caller to rename into place
+func writeTemp(path string, data []byte, perm os.FileMode) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Outgoing message states. Sent messages leave the outbox.
+const (
+	StatusPending = "pending" // handed to the phone, no answer yet
+	StatusQueued  = "queued"  // waiting for the phone to come back
+	StatusFailed  = "failed"
+	StatusSent    = "sent"
+
+	StatusScheduled = "scheduled" // waiting in the schedule for its time
+)
+
+// OutboxEntry is a message the user sent that the phone has not accepted yet
+type OutboxEntry struct {
+	Message *Message `json:"message"`
+	Error   string   `json:"error,omitempty"` // why the last attempt failed
+}
+
+// outboxPath returns the path to the outbox file
+func (s *Store) outboxPath() (string, error) {
+	dir, err := s.dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "outbox.json"), nil
+}
+
+// LoadOutbox restores unsent messages from disk into their conversations.
+// Messages that were still pending when the app exited are marked failed,
+// since there is no telling whether the phone got them. Queued messages never
+// reached the phone, so they stay queued.
+func (s *Store) LoadOutbox() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.outboxPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := s.readSealed(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries []*OutboxEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		msg := entry.Message
+		if msg == nil || msg.ID == "" {
+			continue
+		}
+		if msg.Status != StatusFailed && msg.Status != StatusQueued {
+			msg.Status = StatusFailed
+			entry.Error = "interrupted before the phone answered"
+		}
+		s.outbox[msg.ID] = entry
+		s.messages[msg.ConversationID] = append(s.messages[msg.ConversationID], msg)
+	}
+	return nil
+}
+
+// saveOutbox writes the outbox to disk.
+// Must be called with mutex held.
+func (s *Store) saveOutbox() error {
+	path, err := s.outboxPath()
+	if err != nil {
+		return err
+	}
+
+	entries := make([]*OutboxEntry, 0, len(s.outbox))
+	for _, entry := range s.outbox {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Message.Timestamp.Before(entries[j].Message.Timestamp)
+	})
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.writeSealed(path, data)
+}
+
+// QueueMessage shows an outgoing message in its conversation right away and
+// keeps it in the outbox until the phone accepts it. The message ID is its
+// temporary ID.
+func (s *Store) QueueMessage(msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queueMessage(msg)
+}
+
+// queueMessage adds an outgoing message to its conversation and the outbox.
+// Must be called with mutex held.
+func (s *Store) queueMessage(msg *Message) error {
+	msg.TmpID = msg.ID
+	msg.Status = StatusPending
+	s.messages[msg.ConversationID] = appendInOrder(s.messages[msg.ConversationID], msg)
+	s.outbox[msg.ID] = &OutboxEntry{Message: msg}
+	if conv, ok := s.conversations[msg.ConversationID]; ok {
+		conv.LatestMessage = msg.Content
+		conv.LatestTimestamp = msg.Timestamp
+	}
+	return s.saveOutbox()
+}
+
+// SetOutgoingStatus records the result of sending an outbox message and
+// returns the updated local echo. Sent messages leave the outbox. The
+// returned message is nil if the phone's copy already replaced the echo.
+func (s *Store) SetOutgoingStatus(conversationID, tmpID, status, errText string) (*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var updated *Message
+	msgs := s.messages[conversationID]
+	for i, msg := range msgs {
+		if msg.ID == tmpID {
+			m := *msg
+			m.Status = status
+			msgs[i] = &m
+			updated = &m
+			break
+		}
+	}
+
+	if _, ok := s.outbox[tmpID]; !ok {
+		return updated, nil
+	}
+	if status == StatusSent {
+		delete(s.outbox, tmpID)
+	} else if updated != nil {
+		s.outbox[tmpID] = &OutboxEntry{Message: updated, Error: errText}
+	}
+	return updated, s.saveOutbox()
+}
+
+// DiscardOutgoing drops an unsent or scheduled message from its
+// conversation and the outbox or schedule
+func (s *Store) DiscardOutgoing(conversationID, tmpID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeMessage(conversationID, tmpID)
+
+	if _, ok := s.schedule[tmpID]; ok {
+		delete(s.schedule, tmpID)
+		return s.saveSchedule()
+	}
+	if _, ok := s.outbox[tmpID]; !ok {
+		return nil
+	}
+	delete(s.outbox, tmpID)
+	return s.saveOutbox()
+}
+
+// QueuedMessages returns the outbox messages waiting for the phone, oldest
+// first
+func (s *Store) QueuedMessages() []*Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var queued []*Message
+	for _, entry := range s.outbox {
+		if entry.Message.Status == StatusQueued {
+			queued = append(queued, entry.Message)
+		}
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].Timestamp.Before(queued[j].Timestamp)
+	})
+	return queued
+}
+
+// OutboxError returns why an outbox message last failed to send
+func (s *Store) OutboxError(tmpID string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if entry, ok := s.outbox[tmpID]; ok {
+		return entry.Error
+	}
+	return ""
+}
+
+// matchEcho finds the local echo that a message from the phone replaces.
+// Returns the index of the echo, or -1.
+// Must be called with mutex held.
+func (s *Store) matchEcho(msgs []*Message, msg *Message) int {
+	if msg.TmpID == "" || msg.TmpID == msg.ID {
+		return -1
+	}
+	for i, m := range msgs {
+		if m.ID == msg.TmpID {
+			if _, ok := s.outbox[m.ID]; ok {
+				delete(s.outbox, m.ID)
+				// A stale outbox file only shows the message as failed after a restart
+				_ = s.saveOutbox()
+			}
+			return i
+		}
+	}
+	return -1
+}
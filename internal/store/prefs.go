@@ -0,0 +1,182 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ConversationPrefs are the settings of a conversation kept on this device
+type ConversationPrefs struct {
+	Pinned     bool      `json:"pinned,omitempty"`
+	Muted      bool      `json:"muted,omitempty"`
+	MutedUntil time.Time `json:"muted_until,omitempty"` // zero mutes until unmuted
+	SIM        string    `json:"sim,omitempty"`         // participant ID of the SIM to send from
+}
+
+// IsMuted returns whether notifications for the conversation are off at now
+func (c *Conversation) IsMuted(now time.Time) bool {
+	return c.Muted && (c.MutedUntil.IsZero() || now.Before(c.MutedUntil))
+}
+
+// prefsPath returns the path to the conversation settings file
+func (s *Store) prefsPath() (string, error) {
+	dir, err := s.dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "conversations.json"), nil
+}
+
+// LoadConversationPrefs restores pins, mutes and chosen SIMs from disk
+func (s *Store) LoadConversationPrefs() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.prefsPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := s.readSealed(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	prefs := make(map[string]*ConversationPrefs)
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return err
+	}
+	s.prefs = prefs
+	for _, conv := range s.conversations {
+		s.applyPrefs(conv)
+	}
+	return nil
+}
+
+// savePrefs writes the conversation settings to disk.
+// Must be called with mutex held.
+func (s *Store) savePrefs() error {
+	path, err := s.prefsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s.prefs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.writeSealed(path, data)
+}
+
+// applyPrefs copies the local settings onto a conversation from the phone.
+// Must be called with mutex held.
+func (s *Store) applyPrefs(conv *Conversation) {
+	p, ok := s.prefs[conv.ID]
+	if !ok {
+		p = &ConversationPrefs{}
+	}
+	conv.Pinned = p.Pinned
+	conv.Muted = p.Muted
+	conv.MutedUntil = p.MutedUntil
+	conv.SIM = p.SIM
+}
+
+// updatePrefs changes the settings of a conversation, dropping settings that
+// are all off, and saves them.
+// Must be called with mutex held.
+func (s *Store) updatePrefs(conversationID string, update func(p *ConversationPrefs)) error {
+	p, ok := s.prefs[conversationID]
+	if !ok {
+		p = &ConversationPrefs{}
+	}
+	update(p)
+	if *p == (ConversationPrefs{}) {
+		delete(s.prefs, conversationID)
+	} else {
+		s.prefs[conversationID] = p
+	}
+	if conv, ok := s.conversations[conversationID]; ok {
+		s.applyPrefs(conv)
+	}
+	return s.savePrefs()
+}
+
+// SetPinned pins a conversation to the top of the list on this device
+func (s *Store) SetPinned(conversationID string, pinned bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updatePrefs(conversationID, func(p *ConversationPrefs) {
+		p.Pinned = pinned
+	})
+}
+
+// SetMuted mutes a conversation until the given time, or until unmuted if
+// until is zero
+func (s *Store) SetMuted(conversationID string, muted bool, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updatePrefs(conversationID, func(p *ConversationPrefs) {
+		p.Muted = muted
+		p.MutedUntil = time.Time{}
+		if muted {
+			p.MutedUntil = until
+		}
+	})
+}
+
+// ExpireMutes unmutes the conversations whose mute ran out by now and
+// returns their IDs
+func (s *Store) ExpireMutes(now time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []string
+	for id, p := range s.prefs {
+		if p.Muted && !p.MutedUntil.IsZero() && !now.Before(p.MutedUntil) {
+			expired = append(expired, id)
+		}
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+
+	var err error
+	for _, id := range expired {
+		err = s.updatePrefs(id, func(p *ConversationPrefs) {
+			p.Muted = false
+			p.MutedUntil = time.Time{}
+		})
+	}
+	return expired, err
+}
+
+// SetArchived records that a conversation was archived or unarchived on the
+// phone
+func (s *Store) SetArchived(conversationID string, archived bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if conv, ok := s.conversations[conversationID]; ok {
+		conv.Archived = archived
+	}
+}
+
+// SetBlocked records that a conversation was blocked or unblocked on the
+// phone. Unblocked conversations go back to the inbox.
+func (s *Store) SetBlocked(conversationID string, blocked bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if conv, ok := s.conversations[conversationID]; ok {
+		conv.Blocked = blocked
+		if !blocked {
+			conv.Archived = false
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// schedulePath returns the path to the schedule file
+func (s *Store) schedulePath() (string, error) {
+	dir, err := s.dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "schedule.json"), nil
+}
+
+// LoadSchedule restores the messages waiting to be sent later from disk into
+// their conversations
+func (s *Store) LoadSchedule() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.schedulePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := s.readSealed(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var msgs []*Message
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		return err
+	}
+
+	for _, msg := range msgs {
+		if msg == nil || msg.ID == "" {
+			continue
+		}
+		msg.TmpID = msg.ID
+		msg.Status = StatusScheduled
+		s.schedule[msg.ID] = msg
+		s.messages[msg.ConversationID] = appendInOrder(s.messages[msg.ConversationID], msg)
+	}
+	return nil
+}
+
+// saveSchedule writes the schedule to disk.
+// Must be called with mutex held.
+func (s *Store) saveSchedule() error {
+	path, err := s.schedulePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s.scheduled(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.writeSealed(path, data)
+}
+
+// scheduled returns the scheduled messages, soonest first.
+// Must be called with mutex held.
+func (s *Store) scheduled() []*Message {
+	msgs := make([]*Message, 0, len(s.schedule))
+	for _, msg := range s.schedule {
+		msgs = append(msgs, msg)
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		return msgs[i].Timestamp.Before(msgs[j].Timestamp)
+	})
+	return msgs
+}
+
+// ScheduleMessage shows a message in its conversation and keeps it in the
+// schedule until it is sent at its timestamp. The message ID is its
+// temporary ID.
+func (s *Store) ScheduleMessage(msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg.TmpID = msg.ID
+	msg.Status = StatusScheduled
+	s.messages[msg.ConversationID] = appendInOrder(s.messages[msg.ConversationID], msg)
+	s.schedule[msg.ID] = msg
+	return s.saveSchedule()
+}
+
+// Reschedule changes the text and time of a scheduled message and returns
+// the updated copy, or nil if it already left the schedule
+func (s *Store) Reschedule(conversationID, tmpID, content string, at time.Time) (*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg, ok := s.schedule[tmpID]
+	if !ok {
+		return nil, nil
+	}
+	updated := *msg
+	updated.Content = content
+	updated.Timestamp = at
+
+	s.removeMessage(conversationID, tmpID)
+	s.messages[conversationID] = appendInOrder(s.messages[conversationID], &updated)
+	s.schedule[tmpID] = &updated
+	return &updated, s.saveSchedule()
+}
+
+// DueMessages returns the scheduled messages whose time has come by now,
+// soonest first
+func (s *Store) DueMessages(now time.Time) []*Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var due []*Message
+	for _, msg := range s.scheduled() {
+		if msg.Timestamp.After(now) {
+			break
+		}
+		due = append(due, msg)
+	}
+	return due
+}
+
+// ReleaseScheduled moves a scheduled message to the outbox to be sent now,
+// and returns its outbox copy, pending and stamped with the current time.
+// Returns nil if it already left the schedule.
+func (s *Store) ReleaseScheduled(conversationID, tmpID string) (*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg, ok := s.schedule[tmpID]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.schedule, tmpID)
+	s.removeMessage(conversationID, tmpID)
+
+	released := *msg
+	released.Timestamp = time.Now()
+	if err := s.queueMessage(&released); err != nil {
+		return &released, err
+	}
+	return &released, s.saveSchedule()
+}
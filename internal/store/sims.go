@@ -0,0 +1,80 @@
+package store
+
+import "fmt"
+
+// SIM is a SIM card in the phone. The user is a different participant on
+// each SIM, so a SIM is identified by the participant ID of its line.
+type SIM struct {
+	ParticipantID   string `json:"participant_id"`
+	Slot            int    `json:"slot"` // 1 for the first SIM
+	Carrier         string `json:"carrier"`
+	FormattedNumber string `json:"formatted_number"`
+	Color           string `json:"color"`   // hex color the phone shows the SIM in
+	Account         string `json:"account"` // profile of the phone it's in, when several are used at once
+}
+
+// Short returns a short name for the SIM, like "SIM 2"
+func (s SIM) Short() string {
+	return fmt.Sprintf("SIM %d", s.Slot)
+}
+
+// Label returns the SIM's name with its carrier or number
+func (s SIM) Label() string {
+	switch {
+	case s.Carrier != "":
+		return s.Short() + " · " + s.Carrier
+	case s.FormattedNumber != "":
+		return s.Short() + " · " + s.FormattedNumber
+	default:
+		return s.Short()
+	}
+}
+
+// SendingSIM returns the participant ID of the SIM messages to the
+// conversation go out on: the one chosen on this device, or else the phone's
+// default. Empty if the phone hasn't said.
+func (c *Conversation) SendingSIM() string {
+	if c.SIM != "" {
+		return c.SIM
+	}
+	return c.OutgoingID
+}
+
+// SetSIMs replaces the list of SIMs in the phone
+func (s *Store) SetSIMs(sims []SIM) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sims = sims
+}
+
+// GetSIMs returns the SIMs in the phone, in slot order
+func (s *Store) GetSIMs() []SIM {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]SIM(nil), s.sims...)
+}
+
+// GetSIM returns the SIM of a participant ID of the user, or nil if it isn't
+// one of the phone's SIMs
+func (s *Store) GetSIM(participantID string) *SIM {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, sim := range s.sims {
+		if sim.ParticipantID == participantID {
+			return &sim
+		}
+	}
+	return nil
+}
+
+// SetConversationSIM chooses the SIM messages to a conversation go out on,
+// on this device. An empty simID goes back to the phone's default.
+func (s *Store) SetConversationSIM(conversationID, simID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updatePrefs(conversationID, func(p *ConversationPrefs) {
+		p.SIM = simID
+	})
+}
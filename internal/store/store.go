@@ -2,8 +2,10 @@ package store
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -24,29 +26,160 @@ type Session struct {
 
 // Conversation represents a cached conversation
 type Conversation struct {
-	ID              string    `json:"id"`
-	Name            string    `json:"name"`
-	LatestMessage   string    `json:"latest_message"`
-	LatestTimestamp time.Time `json:"latest_timestamp"`
-	Unread          bool      `json:"unread"`
-	IsGroup         bool      `json:"is_group"`
-	Participants    []string  `json:"participants"`
-	AvatarURL       string    `json:"avatar_url"`
+	ID              string        `json:"id"`
+	Name            string        `json:"name"`
+	LatestMessage   string        `json:"latest_message"`
+	LatestTimestamp time.Time     `json:"latest_timestamp"`
+	Unread          bool          `json:"unread"`
+	IsGroup         bool          `json:"is_group"`
+	Participants    []Participant `json:"participants"`
+	AvatarURL       string        `json:"avatar_url"`
+	MarkedUnread    bool          `json:"marked_unread"` // marked unread on this device only
+	Archived        bool          `json:"archived"`
+	Blocked         bool          `json:"blocked"`     // in the phone's spam & blocked folder
+	Pinned          bool          `json:"pinned"`      // pinned on this device only
+	Muted           bool          `json:"muted"`       // see IsMuted
+	MutedUntil      time.Time     `json:"muted_until"` // zero mutes until unmuted
+	OutgoingID      string        `json:"outgoing_id"` // the user's participant ID on the phone's SIM for it
+	SIM             string        `json:"sim"`         // SIM chosen on this device, see SendingSIM
+	Account         string        `json:"account"`     // profile of the phone it's on, when several are used at once
+}
+
+// Participant is a member of a conversation, the user included
+type Participant struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`             // from the contacts if saved there
+	Number          string `json:"number"`           // e.g. +15550100
+	FormattedNumber string `json:"formatted_number"` // number as shown on the phone
+	IsMe            bool   `json:"is_me"`
+	Color           string `json:"color"` // avatar color, #rrggbb
+}
+
+// DisplayName returns the participant's name, or their number if unnamed
+func (p Participant) DisplayName() string {
+	switch {
+	case p.Name != "":
+		return p.Name
+	case p.FormattedNumber != "":
+		return p.FormattedNumber
+	default:
+		return p.Number
+	}
+}
+
+// Participant returns the member with the given participant ID, or nil
+func (c *Conversation) Participant(id string) *Participant {
+	if c == nil || id == "" {
+		return nil
+	}
+	for i := range c.Participants {
+		if c.Participants[i].ID == id {
+			return &c.Participants[i]
+		}
+	}
+	return nil
+}
+
+// Others returns the members of the conversation other than the user
+func (c *Conversation) Others() []Participant {
+	others := make([]Participant, 0, len(c.Participants))
+	for _, p := range c.Participants {
+		if !p.IsMe {
+			others = append(others, p)
+		}
+	}
+	return others
 }
 
 // Message represents a cached message
 type Message struct {
-	ID             string    `json:"id"`
-	ConversationID string    `json:"conversation_id"`
-	SenderID       string    `json:"sender_id"`
-	SenderName     string    `json:"sender_name"`
-	Content        string    `json:"content"`
-	Timestamp      time.Time `json:"timestamp"`
-	IsFromMe       bool      `json:"is_from_me"`
-	Status         string    `json:"status"` // sent, delivered, read, failed
-	Reactions      []string  `json:"reactions"`
-	MediaURL       string    `json:"media_url"`
-	MediaType      string    `json:"media_type"`
+	ID             string     `json:"id"`
+	TmpID          string     `json:"tmp_id"` // temporary ID of the local echo it replaces
+	ConversationID string     `json:"conversation_id"`
+	SenderID       string     `json:"sender_id"`
+	SenderName     string     `json:"sender_name"`
+	SenderNumber   string     `json:"sender_number"` // phone number, for finding the contact
+	Content        string     `json:"content"`
+	Timestamp      time.Time  `json:"timestamp"`
+	IsFromMe       bool       `json:"is_from_me"`
+	Status         string     `json:"status"` // sent, delivered, read, failed
+	Reactions      []Reaction `json:"reactions"`
+	ReplyToID      string     `json:"reply_to_id"` // ID of the message this replies to
+	MediaURL       string     `json:"media_url"`   // local path once downloaded
+	MediaType      string     `json:"media_type"`  // MIME type
+	MediaID        string     `json:"media_id"`
+	MediaName      string     `json:"media_name"`
+	MediaSize      int64      `json:"media_size"`
+	MediaKey       []byte     `json:"media_key"`            // decryption key
+	SIMID          string     `json:"sim_id"`               // SIM a queued message goes out on, empty for the default
+	Attachment     string     `json:"attachment,omitempty"` // file a queued attachment is uploaded from
+}
+
+// Contact is an entry of the phone's contact list
+type Contact struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Number          string `json:"number"`           // number to message, e.g. +15550100
+	FormattedNumber string `json:"formatted_number"` // number as shown on the phone
+}
+
+// Reaction is an emoji reaction on a message and everyone who sent it
+type Reaction struct {
+	Emoji     string   `json:"emoji"`
+	SenderIDs []string `json:"sender_ids"` // participant IDs of everyone who reacted
+	Senders   []string `json:"senders"`    // display names of the other senders
+	FromMe    bool     `json:"from_me"`    // the user is one of the senders
+}
+
+// Count returns how many people sent the reaction
+func (r Reaction) Count() int {
+	if r.FromMe {
+		return len(r.Senders) + 1
+	}
+	return len(r.Senders)
+}
+
+// HasMedia returns whether the message carries an attachment
+func (m *Message) HasMedia() bool {
+	return m.MediaID != "" || m.Attachment != ""
+}
+
+// IsLocal returns whether the message is a local echo the phone hasn't
+// confirmed yet
+func (m *Message) IsLocal() bool {
+	return m.TmpID != "" && m.ID == m.TmpID
+}
+
+// WithMyReaction returns a copy of the message with the user's reaction
+// replaced by emoji, or removed if emoji is empty. The message itself is left
+// untouched so readers holding it never see it change.
+func (m *Message) WithMyReaction(emoji string) *Message {
+	reactions := make([]Reaction, 0, len(m.Reactions)+1)
+	found := false
+	for _, r := range m.Reactions {
+		r.FromMe = r.Emoji == emoji
+		found = found || r.FromMe
+		if r.Count() > 0 {
+			reactions = append(reactions, r)
+		}
+	}
+	if emoji != "" && !found {
+		reactions = append(reactions, Reaction{Emoji: emoji, FromMe: true})
+	}
+
+	updated := *m
+	updated.Reactions = reactions
+	return &updated
+}
+
+// MyReaction returns the emoji the user reacted with, or "" if none
+func (m *Message) MyReaction() string {
+	for _, r := range m.Reactions {
+		if r.FromMe {
+			return r.Emoji
+		}
+	}
+	return ""
 }
 
 // Store manages session and message caching
@@ -54,32 +187,66 @@ type Store struct {
 	mu            sync.RWMutex
 	session       *Session
 	conversations map[string]*Conversation
-	messages      map[string][]*Message // keyed by conversation ID
+	messages      map[string][]*Message         // keyed by conversation ID
+	mediaIndex    map[string]string             // media ID -> cached file, loaded lazily
+	outbox        map[string]*OutboxEntry       // keyed by temporary message ID
+	schedule      map[string]*Message           // messages to send later, keyed by temporary ID
+	contacts      map[string]*Contact           // keyed by phone number, see numberKey
+	prefs         map[string]*ConversationPrefs // keyed by conversation ID
+	sims          []SIM                         // the phone's SIMs, in slot order
+	profile       string                        // profile whose files are used, empty for the current one
+
+	// Encryption of the store's files, see SetSecret
+	secret    []byte
+	salt      []byte            // salt of the current file key
+	keys      map[string][]byte // derived file keys, see deriveKey
+	revealDir string            // decrypted attachments, see revealedPath
 }
 
-// New creates a new Store instance
+// New creates a new Store instance using the files of the current profile
 func New() *Store {
+	return NewForProfile("")
+}
+
+// NewForProfile creates a new Store instance using the files of a profile,
+// or the current profile if name is empty
+func NewForProfile(name string) *Store {
 	return &Store{
+		profile:       name,
 		conversations: make(map[string]*Conversation),
 		messages:      make(map[string][]*Message),
+		outbox:        make(map[string]*OutboxEntry),
+		schedule:      make(map[string]*Message),
+		contacts:      make(map[string]*Contact),
+		prefs:         make(map[string]*ConversationPrefs),
+		keys:          make(map[string][]byte),
 	}
 }
 
+// dir returns the directory holding the store's files
+func (s *Store) dir() (string, error) {
+	if s.profile != "" {
+		return config.ProfileDir(s.profile)
+	}
+	return config.ConfigDir()
+}
+
 // sessionPath returns the path to the session file
-func sessionPath() (string, error) {
-	dir, err := config.ConfigDir()
+func (s *Store) sessionPath() (string, error) {
+	dir, err := s.dir()
 	if err != nil {
 		return "", err
 	}
 	return filepath.Join(dir, "session.json"), nil
 }
 
-// LoadSession loads the session from disk
+// LoadSession loads the session from disk, decrypting it with the secret.
+// A session saved in the clear is encrypted in place if a secret is set.
 func (s *Store) LoadSession() (*Session, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	path, err := sessionPath()
+	path, err := s.sessionPath()
 	if err != nil {
 		return nil, err
 	}
@@ -92,41 +259,164 @@ func (s *Store) LoadSession() (*Session, error) {
 		return nil, err
 	}
 
+	data, encrypted, err := s.open(data)
+	if err != nil {
+		return nil, err
+	}
+
 	var session Session
 	if err := json.Unmarshal(data, &session); err != nil {
 		return nil, err
 	}
 
+	if !encrypted && s.secret != nil {
+		if err := s.writeSession(&session); err != nil {
+			return nil, fmt.Errorf("failed to encrypt session: %w", err)
+		}
+	}
+
 	s.session = &session
 	return &session, nil
 }
 
-// SaveSession saves the session to disk
+// SaveSession saves the session to disk, replacing the old file atomically
 func (s *Store) SaveSession(session *Session) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if err := config.EnsureConfigDir(); err != nil {
+	session.LastUsed = time.Now()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+
+	if err := s.writeSession(session); err != nil {
 		return err
 	}
+	s.session = session
+	return nil
+}
 
-	path, err := sessionPath()
+// writeSession writes the session file, encrypted if a secret is set.
+// Must be called with mutex held.
+func (s *Store) writeSession(session *Session) error {
+	path, err := s.sessionPath()
 	if err != nil {
 		return err
 	}
 
-	session.LastUsed = time.Now()
-	if session.CreatedAt.IsZero() {
-		session.CreatedAt = time.Now()
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	if data, err = s.seal(data); err != nil {
+		return err
 	}
 
-	data, err := json.MarshalIndent(session, "", "  ")
+	// A torn write would lose the pairing, which only re-pairing brings back
+	return writeFileAtomic(path, data, 0600)
+}
+
+// Rekey encrypts the loaded session and the store's other files with a new
+// secret. A store without a saved session only has its other files.
+func (s *Store) Rekey(secret []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.session == nil {
+		exists, _, err := s.SavedSession()
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("no session loaded")
+		}
+	}
+	paths, err := s.sealedPaths()
 	if err != nil {
 		return err
 	}
 
-	s.session = session
-	return os.WriteFile(path, data, 0600)
+	// Every file is re-encrypted next to the old one first. Only once all of
+	// them are written are they renamed into place, the session last, so it
+	// stays on the old secret unless everything else moved over.
+	next := &Store{keys: make(map[string][]byte)}
+	next.setSecret(secret)
+	type stagedFile struct{ path, tmp string }
+	var staged []stagedFile
+	defer func() {
+		for _, f := range staged {
+			os.Remove(f.tmp)
+		}
+	}()
+	stage := func(path string, plain []byte) error {
+		data, err := next.seal(plain)
+		if err != nil {
+			return err
+		}
+		tmp, err := writeTemp(path, data, 0600)
+		if err != nil {
+			return err
+		}
+		staged = append(staged, stagedFile{path, tmp})
+		return nil
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		plain, _, err := s.open(data)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+		}
+		if err := stage(path, plain); err != nil {
+			return err
+		}
+	}
+	if s.session != nil {
+		sessionPath, err := s.sessionPath()
+		if err != nil {
+			return err
+		}
+		session, err := json.MarshalIndent(s.session, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := stage(sessionPath, session); err != nil {
+			return err
+		}
+	}
+
+	for len(staged) > 0 {
+		f := staged[0]
+		if err := os.Rename(f.tmp, f.path); err != nil {
+			return fmt.Errorf("failed to re-encrypt %s, the session is still on the old secret: %w", filepath.Base(f.path), err)
+		}
+		staged = staged[1:]
+	}
+	s.secret, s.salt, s.keys = next.secret, next.salt, next.keys
+	return nil
+}
+
+// SavedSession reports whether there is a saved session and whether it is
+// encrypted, without opening it
+func (s *Store) SavedSession() (exists, encrypted bool, err error) {
+	path, err := s.sessionPath()
+	if err != nil {
+		return false, false, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return true, isSealed(data), nil
 }
 
 // ClearSession removes the session from disk
@@ -136,7 +426,7 @@ func (s *Store) ClearSession() error {
 
 	s.session = nil
 
-	path, err := sessionPath()
+	path, err := s.sessionPath()
 	if err != nil {
 		return err
 	}
@@ -162,18 +452,31 @@ func (s *Store) GetSession() *Session {
 	return s.session
 }
 
-// SetConversations updates the conversation list
+// SetConversations merges a page of conversations into the conversation list
 func (s *Store) SetConversations(convs []*Conversation) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.conversations = make(map[string]*Conversation)
 	for _, c := range convs {
-		s.conversations[c.ID] = c
+		s.putConversation(c)
 	}
 }
 
-// GetConversations returns all conversations sorted by latest timestamp
+// putConversation stores a conversation from the phone in place of the
+// cached one.
+// Must be called with mutex held.
+func (s *Store) putConversation(c *Conversation) {
+	// The phone knows nothing of local unread marks, keep them
+	if old, ok := s.conversations[c.ID]; ok && old.MarkedUnread {
+		c.MarkedUnread = true
+		c.Unread = true
+	}
+	s.applyPrefs(c)
+	s.conversations[c.ID] = c
+}
+
+// GetConversations returns all conversations, pinned ones first, sorted by
+// latest timestamp
 func (s *Store) GetConversations() []*Conversation {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -186,6 +489,12 @@ func (s *Store) GetConversations() []*Conversation {
 	// Sort by latest timestamp (newest first)
 	for i := 0; i < len(convs)-1; i++ {
 		for j := i + 1; j < len(convs); j++ {
+			if convs[j].Pinned != convs[i].Pinned {
+				if convs[j].Pinned {
+					convs[i], convs[j] = convs[j], convs[i]
+				}
+				continue
+			}
 			if convs[j].LatestTimestamp.After(convs[i].LatestTimestamp) {
 				convs[i], convs[j] = convs[j], convs[i]
 			}
@@ -206,7 +515,7 @@ func (s *Store) GetConversation(id string) *Conversation {
 func (s *Store) UpdateConversation(conv *Conversation) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.conversations[conv.ID] = conv
+	s.putConversation(conv)
 }
 
 // SetMessages sets messages for a conversation
@@ -216,29 +525,136 @@ func (s *Store) SetMessages(conversationID string, msgs []*Message) {
 	s.messages[conversationID] = msgs
 }
 
-// GetMessages returns messages for a conversation
+// MergeMessages merges a page of messages into a conversation's history,
+// replacing messages with the same ID and keeping the history in time order
+func (s *Store) MergeMessages(conversationID string, msgs []*Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.messages[conversationID]
+	index := make(map[string]int, len(existing))
+	for i, m := range existing {
+		index[m.ID] = i
+	}
+
+	merged := existing
+	for _, m := range msgs {
+		if i, ok := index[m.ID]; ok {
+			merged[i] = m
+			continue
+		}
+		if i := s.matchEcho(merged, m); i >= 0 {
+			index[m.ID] = i
+			merged[i] = m
+			continue
+		}
+		index[m.ID] = len(merged)
+		merged = append(merged, m)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Timestamp.Before(merged[j].Timestamp)
+	})
+	s.messages[conversationID] = merged
+}
+
+// GetMessages returns a copy of the messages for a conversation
 func (s *Store) GetMessages(conversationID string) []*Message {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.messages[conversationID]
+	return append([]*Message(nil), s.messages[conversationID]...)
 }
 
-// AddMessage adds a message to a conversation
-func (s *Store) AddMessage(msg *Message) {
+// AddMessage adds a message to a conversation, or replaces the cached message
+// with the same ID. Returns true if the message was not cached before.
+func (s *Store) AddMessage(msg *Message) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	msgs := s.messages[msg.ConversationID]
-	s.messages[msg.ConversationID] = append(msgs, msg)
+	for i, m := range msgs {
+		if m.ID == msg.ID {
+			// Downloaded attachments are only known locally
+			if msg.MediaURL == "" && msg.MediaID == m.MediaID {
+				msg.MediaURL = m.MediaURL
+			}
+			msgs[i] = msg
+			return false
+		}
+	}
+	// The phone's copy of a message sent from here replaces the local echo
+	if i := s.matchEcho(msgs, msg); i >= 0 {
+		msgs[i] = msg
+		return false
+	}
+	s.messages[msg.ConversationID] = appendInOrder(msgs, msg)
 
-	// Update conversation's latest message
-	if conv, ok := s.conversations[msg.ConversationID]; ok {
+	// Update conversation's latest message, unless an older message of a
+	// conversation whose history isn't loaded turned up
+	if conv, ok := s.conversations[msg.ConversationID]; ok && !msg.Timestamp.Before(conv.LatestTimestamp) {
 		conv.LatestMessage = msg.Content
 		conv.LatestTimestamp = msg.Timestamp
 		if !msg.IsFromMe {
 			conv.Unread = true
 		}
 	}
+	return true
+}
+
+// appendInOrder adds a message to a conversation's history, keeping it in
+// time order
+func appendInOrder(msgs []*Message, msg *Message) []*Message {
+	msgs = append(msgs, msg)
+	if n := len(msgs); n > 1 && msg.Timestamp.Before(msgs[n-2].Timestamp) {
+		sort.SliceStable(msgs, func(i, j int) bool {
+			return msgs[i].Timestamp.Before(msgs[j].Timestamp)
+		})
+	}
+	return msgs
+}
+
+// SetMyReaction replaces the user's reaction on a message. An empty emoji
+// removes it. Returns false if the message is not cached.
+func (s *Store) SetMyReaction(conversationID, messageID, emoji string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msgs := s.messages[conversationID]
+	for i, msg := range msgs {
+		if msg.ID == messageID {
+			msgs[i] = msg.WithMyReaction(emoji)
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveMessage drops a deleted message from its conversation. Returns false
+// if the message is not cached.
+func (s *Store) RemoveMessage(conversationID, messageID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.removeMessage(conversationID, messageID)
+}
+
+// removeMessage drops a message and, if it was the newest, shows the one
+// before it as the conversation's latest message.
+// Must be called with mutex held.
+func (s *Store) removeMessage(conversationID, messageID string) bool {
+	msgs := s.messages[conversationID]
+	for i, msg := range msgs {
+		if msg.ID != messageID {
+			continue
+		}
+		msgs = append(msgs[:i:i], msgs[i+1:]...)
+		s.messages[conversationID] = msgs
+		if conv, ok := s.conversations[conversationID]; ok && i == len(msgs) && len(msgs) > 0 && msg.Status != StatusScheduled {
+			conv.LatestMessage = msgs[len(msgs)-1].Content
+			conv.LatestTimestamp = msgs[len(msgs)-1].Timestamp
+		}
+		return true
+	}
+	return false
 }
 
 // MarkConversationRead marks a conversation as read
@@ -248,5 +664,28 @@ func (s *Store) MarkConversationRead(conversationID string) {
 
 	if conv, ok := s.conversations[conversationID]; ok {
 		conv.Unread = false
+		conv.MarkedUnread = false
+	}
+}
+
+// MarkConversationUnread marks a conversation as unread on this device
+func (s *Store) MarkConversationUnread(conversationID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if conv, ok := s.conversations[conversationID]; ok {
+		conv.Unread = true
+		conv.MarkedUnread = true
+	}
+}
+
+// ClearMarkedUnread drops a local unread mark, leaving the unread state to
+// the phone again
+func (s *Store) ClearMarkedUnread(conversationID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if conv, ok := s.conversations[conversationID]; ok {
+		conv.MarkedUnread = false
 	}
 }
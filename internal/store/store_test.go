@@ -0,0 +1,180 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeMessages(t *testing.T) {
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	tests := []struct {
+		name     string
+		existing []*Message
+		queued   *Message // local echo in the outbox, if any
+		page     []*Message
+		want     []string // IDs in order
+		content  map[string]string
+		outbox   int
+	}{
+		{
+			name:     "appends new messages in time order",
+			existing: []*Message{{ID: "b", Timestamp: at(2)}},
+			page:     []*Message{{ID: "c", Timestamp: at(3)}, {ID: "a", Timestamp: at(1)}},
+			want:     []string{"a", "b", "c"},
+		},
+		{
+			name:     "replaces a message with the same ID",
+			existing: []*Message{{ID: "a", Content: "old", Timestamp: at(1)}},
+			page:     []*Message{{ID: "a", Content: "new", Timestamp: at(1)}},
+			want:     []string{"a"},
+			content:  map[string]string{"a": "new"},
+		},
+		{
+			name:    "phone's copy replaces the local echo",
+			queued:  &Message{ID: "tmp_1", Content: "hi", Timestamp: at(1)},
+			page:    []*Message{{ID: "m1", TmpID: "tmp_1", Content: "hi", Timestamp: at(1)}},
+			want:    []string{"m1"},
+			content: map[string]string{"m1": "hi"},
+		},
+		{
+			name:   "keeps the echo for an unrelated message",
+			queued: &Message{ID: "tmp_1", Timestamp: at(1)},
+			page:   []*Message{{ID: "m1", TmpID: "tmp_2", Timestamp: at(2)}},
+			want:   []string{"tmp_1", "m1"},
+			outbox: 1,
+		},
+		{
+			name:   "a TmpID equal to the ID matches no echo",
+			queued: &Message{ID: "tmp_1", Timestamp: at(1)},
+			page:   []*Message{{ID: "m1", TmpID: "m1", Timestamp: at(2)}},
+			want:   []string{"tmp_1", "m1"},
+			outbox: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			s := New()
+			s.SetMessages("c1", tt.existing)
+			if tt.queued != nil {
+				tt.queued.ConversationID = "c1"
+				if err := s.QueueMessage(tt.queued); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, m := range tt.page {
+				m.ConversationID = "c1"
+			}
+
+			s.MergeMessages("c1", tt.page)
+
+			got := s.GetMessages("c1")
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d messages, want %v", len(got), tt.want)
+			}
+			for i, m := range got {
+				if m.ID != tt.want[i] {
+					t.Errorf("message %d is %s, want %s", i, m.ID, tt.want[i])
+				}
+				if want, ok := tt.content[m.ID]; ok && m.Content != want {
+					t.Errorf("message %s has content %q, want %q", m.ID, m.Content, want)
+				}
+			}
+			if n := len(s.outbox); n != tt.outbox {
+				t.Errorf("outbox holds %d messages, want %d", n, tt.outbox)
+			}
+		})
+	}
+}
+
+func TestAddMessage(t *testing.T) {
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		existing   []*Message
+		queued     *Message
+		msg        *Message
+		wantNew    bool
+		want       []string
+		wantLatest string
+		wantUnread bool
+	}{
+		{
+			name:       "new incoming message",
+			msg:        &Message{ID: "m1", Content: "hello", Timestamp: base},
+			wantNew:    true,
+			want:       []string{"m1"},
+			wantLatest: "hello",
+			wantUnread: true,
+		},
+		{
+			name:       "status update of a cached message",
+			existing:   []*Message{{ID: "m1", Content: "hello", Status: "sent", Timestamp: base}},
+			msg:        &Message{ID: "m1", Content: "hello", Status: "read", Timestamp: base},
+			want:       []string{"m1"},
+			wantLatest: "earlier",
+		},
+		{
+			name:       "phone's copy of a message sent from here",
+			queued:     &Message{ID: "tmp_1", Content: "hi", Timestamp: base},
+			msg:        &Message{ID: "m1", TmpID: "tmp_1", Content: "hi", IsFromMe: true, Timestamp: base},
+			want:       []string{"m1"},
+			wantLatest: "hi",
+		},
+		{
+			name:       "older message doesn't become the latest",
+			msg:        &Message{ID: "m0", Content: "ancient", Timestamp: base.Add(-48 * time.Hour)},
+			wantNew:    true,
+			want:       []string{"m0"},
+			wantLatest: "earlier",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			s := New()
+			s.SetConversations([]*Conversation{{
+				ID:              "c1",
+				LatestMessage:   "earlier",
+				LatestTimestamp: base.Add(-time.Hour),
+			}})
+			s.SetMessages("c1", tt.existing)
+			if tt.queued != nil {
+				tt.queued.ConversationID = "c1"
+				if err := s.QueueMessage(tt.queued); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tt.msg.ConversationID = "c1"
+
+			if got := s.AddMessage(tt.msg); got != tt.wantNew {
+				t.Errorf("AddMessage returned %v, want %v", got, tt.wantNew)
+			}
+
+			got := s.GetMessages("c1")
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d messages, want %v", len(got), tt.want)
+			}
+			for i, m := range got {
+				if m.ID != tt.want[i] {
+					t.Errorf("message %d is %s, want %s", i, m.ID, tt.want[i])
+				}
+			}
+			if len(s.outbox) != 0 {
+				t.Errorf("outbox still holds %d messages", len(s.outbox))
+			}
+			conv := s.GetConversation("c1")
+			if conv.LatestMessage != tt.wantLatest {
+				t.Errorf("latest message is %q, want %q", conv.LatestMessage, tt.wantLatest)
+			}
+			if conv.Unread != tt.wantUnread {
+				t.Errorf("unread is %v, want %v", conv.Unread, tt.wantUnread)
+			}
+		})
+	}
+}
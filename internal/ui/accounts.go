@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/n0ko/messages-tui/internal/client"
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// pairingAccountMsg says which account is being connected or paired
+type pairingAccountMsg struct {
+	name string
+}
+
+// SetAccounts tells the app the backend runs several accounts, in the order
+// they are listed in. Must be called before the program starts.
+func (a *App) SetAccounts(names []string) {
+	a.accounts = names
+	a.contacts.SetAccounts(names)
+}
+
+// SetPairingAccount sends the name of the account being connected or paired
+// to the app through the message channel
+func (a *App) SetPairingAccount(name string) {
+	a.externalMsgs <- pairingAccountMsg{name: name}
+}
+
+// accountNames returns the names of the accounts, or a single empty name
+// when the backend runs only one
+func (a *App) accountNames() []string {
+	if len(a.accounts) == 0 {
+		return []string{""}
+	}
+	return a.accounts
+}
+
+// conversationAccount returns the account a conversation is on, empty when
+// the backend runs only one
+func (a *App) conversationAccount(conversationID string) string {
+	return client.AccountOf(conversationID)
+}
+
+// activeAccount returns the account the user is working in: the one of the
+// conversation selected in the contacts panel, or else of the open one.
+// Empty when the backend runs only one.
+func (a *App) activeAccount() string {
+	if len(a.accounts) == 0 {
+		return ""
+	}
+	if conv := a.contacts.SelectedConversation(); conv != nil && a.focusedPanel == PanelContacts {
+		return conv.Account
+	}
+	if account := a.conversationAccount(a.activeConversationID); account != "" {
+		return account
+	}
+	return a.accounts[0]
+}
+
+// accountSIMs returns the SIMs of the phone an account is on
+func (a *App) accountSIMs(account string) []store.SIM {
+	sims := a.store.GetSIMs()
+	return slices.DeleteFunc(sims, func(sim store.SIM) bool {
+		return sim.Account != account
+	})
+}
+
+// SetAccounts sets the accounts conversations are grouped by, in order
+func (m *ContactsModel) SetAccounts(names []string) {
+	m.accounts = names
+}
+
+// groupByAccount orders conversations by account, keeping their order
+// within each account
+func (m ContactsModel) groupByAccount(convs []*store.Conversation) {
+	if len(m.accounts) < 2 {
+		return
+	}
+	sort.SliceStable(convs, func(i, j int) bool {
+		return slices.Index(m.accounts, convs[i].Account) < slices.Index(m.accounts, convs[j].Account)
+	})
+}
+
+// selectNextAccount selects the first conversation of the next account in
+// the list, coming back round to the first, and scrolls it to the top
+func (m *ContactsModel) selectNextAccount() {
+	convs := m.getFilteredConversations()
+	if len(m.accounts) < 2 || len(convs) == 0 {
+		return
+	}
+	current := ""
+	if m.selected < len(convs) {
+		current = convs[m.selected].Account
+	}
+	for i := 1; i < len(convs); i++ {
+		next := (m.selected + i) % len(convs)
+		if convs[next].Account == current {
+			continue
+		}
+		m.selected = next
+		m.offset = next
+		return
+	}
+}
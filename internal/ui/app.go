@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"mime"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -22,6 +25,7 @@ type AppState int
 const (
 	StateLoading AppState = iota
 	StateQRPairing
+	StateEmojiPairing
 	StateConnected
 	StateError
 )
@@ -70,6 +74,9 @@ func DefaultAppKeyMap() AppKeyMap {
 	}
 }
 
+// backgroundPageDelay is the pause between background conversation page fetches
+const backgroundPageDelay = 2 * time.Second
+
 // App is the main application model
 type App struct {
 	// Configuration
@@ -83,6 +90,23 @@ type App struct {
 	err              error
 	statusMsg        string
 	leaderKeyPressed bool // Track if leader key was pressed (for leader+key combos)
+	initialized      bool // The first connection set the app up
+
+	// Reconnect status shown in the status bar
+	reconnecting bool
+	reconnect    client.ReconnectInfo
+
+	// What each account's phone last said about itself, shown in the status
+	// bar. Keyed by account, the only phone is under "".
+	phones map[string]client.PhoneStatus
+
+	// When the session runs out if its token isn't refreshed, zero unless
+	// the client warned about it, and the account it belongs to
+	sessionExpiry  time.Time
+	sessionAccount string
+
+	// Accounts of the backend in order, nil unless it runs several
+	accounts []string
 
 	// Size
 	width  int
@@ -96,14 +120,56 @@ type App struct {
 	// Active conversation for messaging (set by pressing Enter in contacts)
 	activeConversationID string
 
+	// Conversation list paging
+	conversationCursor string // cursor of the next page, empty when all are loaded
+	loadingPage        bool
+
+	// Who is typing, by conversation ID and name, until when
+	typing map[string]map[string]time.Time
+
+	// Message waiting for the user to confirm its deletion
+	confirmDelete *store.Message
+
+	// New conversation dialog, while open
+	newChat *NewChatModel
+
+	// Conversation whose members are listed, while the roster is open
+	roster *store.Conversation
+
+	// Conversation being muted, while the mute duration chooser is open
+	muting *store.Conversation
+
+	// Conversation waiting for the user to confirm blocking it
+	blocking *store.Conversation
+
+	// Conversation whose SIM is being chosen, while the SIM chooser is open
+	choosingSIM *store.Conversation
+
+	// Scheduled messages that came due while the app was closed, while the
+	// user is asked whether to send them
+	overdue []*store.Message
+
+	// Overdue scheduled messages the user chose not to send yet, by ID
+	keptOverdue map[string]bool
+
+	// Message history paging, keyed by conversation ID. An empty cursor means
+	// the beginning of the conversation has been reached.
+	olderCursors map[string]string
+
 	// QR pairing
 	qrURL string
 
+	// Google account pairing, the emoji to tap on the phone
+	pairingEmoji string
+
+	// Account being connected or paired, when the backend runs several
+	pairingAccount string
+
 	// External message channel for receiving messages from outside Bubble Tea loop
 	externalMsgs chan tea.Msg
 
 	// Backend
-	client *client.Client
+	client client.Messenger
 	store  *store.Store
 
 	// Context for cancellation
@@ -112,7 +178,7 @@ type App struct {
 }
 
 // NewApp creates a new application instance
-func NewApp(cfg *config.Config, st *store.Store, cl *client.Client) *App {
+func NewApp(cfg *config.Config, st *store.Store, cl client.Messenger) *App {
 	ctx, cancel := context.WithCancel(context.Background())
 	styles := DefaultStyles()
 
@@ -129,6 +195,10 @@ func NewApp(cfg *config.Config, st *store.Store, cl *client.Client) *App {
 		ctx:          ctx,
 		cancel:       cancel,
 		externalMsgs: make(chan tea.Msg, 10),
+		olderCursors: make(map[string]string),
+		typing:       make(map[string]map[string]time.Time),
+		phones:       make(map[string]client.PhoneStatus),
+		keptOverdue:  make(map[string]bool),
 	}
 }
 
@@ -165,6 +235,8 @@ func (a *App) Init() tea.Cmd {
 		a.input.Init(),
 		a.listenForEvents(),
 		a.listenForExternalMsgs(),
+		scheduleMuteCheck(),
+		scheduleSendLaterCheck(),
 	)
 }
 
@@ -191,6 +263,44 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.updateSizes()
 
 	case tea.KeyMsg:
+		// The overdue scheduled messages question takes every key
+		if a.overdue != nil {
+			return a, a.handleOverdueKey(msg)
+		}
+		// So does the delete confirmation dialog
+		if a.confirmDelete != nil {
+			return a, a.handleDeleteConfirmKey(msg)
+		}
+		// So does the new conversation dialog
+		if a.newChat != nil {
+			newChat, cmd, closed := a.newChat.Update(msg)
+			if closed {
+				a.newChat = nil
+				a.statusMsg = ""
+			} else {
+				a.newChat = &newChat
+			}
+			return a, cmd
+		}
+		// And the roster
+		if a.roster != nil {
+			a.handleRosterKey(msg)
+			return a, nil
+		}
+		// And the mute duration chooser
+		if a.muting != nil {
+			return a, a.handleMuteChoiceKey(msg)
+		}
+		// And the block confirmation
+		if a.blocking != nil {
+			return a, a.handleBlockConfirmKey(msg)
+		}
+		// And the SIM chooser
+		if a.choosingSIM != nil {
+			a.handleSIMChoiceKey(msg)
+			return a, nil
+		}
+
 		// Handle leader key combinations first
 		if a.leaderKeyPressed {
 			// Escape cancels leader mode
@@ -221,13 +331,21 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch {
 		case key.Matches(msg, a.keyMap.Quit):
 			// Don't quit if input is focused and has content
-			if a.focusedPanel == PanelInput && a.input.Value() != "" {
+			if a.focusedPanel == PanelInput && (a.input.Value() != "" || a.input.Attaching() || a.input.Scheduling()) {
+				break
+			}
+			// Don't quit while choosing a reaction
+			if a.focusedPanel == PanelMessages && a.messages.Picking() {
 				break
 			}
 			a.cancel()
 			return a, tea.Quit
 
 		case key.Matches(msg, a.keyMap.Tab):
+			// Tab completes file paths while attaching
+			if a.focusedPanel == PanelInput && a.input.Attaching() {
+				break
+			}
 			a.cycleFocus(1)
 			return a, nil
 
@@ -244,10 +362,119 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case client.Event:
 		cmds = append(cmds, a.handleClientEvent(msg))
+		// Keep listening for further client events
+		cmds = append(cmds, a.listenForEvents())
 
 	case SendMessageMsg:
 		log.Printf("App: SendMessageMsg received, content length: %d", len(msg.Content))
-		cmds = append(cmds, a.sendMessage(msg.Content))
+		cmds = append(cmds, a.queueMessage(msg.Content, msg.ReplyToID, msg.SIMID))
+
+	case ScheduleMessageMsg:
+		a.scheduleMessage(msg)
+
+	case EditScheduledMsg:
+		a.editScheduled(msg.Message)
+
+	case sendLaterTickMsg:
+		cmds = append(cmds, a.sendDueMessages(), scheduleSendLaterCheck())
+
+	case ReplyMsg:
+		a.focusPanel(PanelInput)
+		a.input.BeginReply(msg.Message)
+		a.statusMsg = "Replying to " + quoteSender(msg.Message)
+
+	case outgoingResultMsg:
+		a.handleOutgoingResult(msg)
+
+	case RetryMessageMsg:
+		cmds = append(cmds, a.retryMessage(msg.Message))
+
+	case DiscardMessageMsg:
+		a.discardMessage(msg.Message)
+
+	case NewConversationMsg:
+		newChat := NewNewChatModel(a.styles)
+		newChat.account = a.activeAccount()
+		// Offer the contacts from the last sync while fetching fresh ones
+		if cached := a.store.GetContacts(); len(cached) > 0 {
+			newChat.SetContacts(cached)
+		}
+		a.newChat = &newChat
+		cmds = append(cmds, a.fetchContacts())
+
+	case contactsFetchedMsg:
+		if msg.err == nil {
+			a.refreshNames()
+		}
+		if a.newChat == nil {
+			break
+		}
+		if msg.err != nil {
+			a.newChat.SetError(msg.err)
+			break
+		}
+		a.newChat.SetContacts(a.store.GetContacts())
+
+	case CreateConversationMsg:
+		cmds = append(cmds, a.createConversation(msg.Numbers))
+
+	case conversationCreatedMsg:
+		if msg.err != nil {
+			if a.newChat != nil {
+				a.newChat.SetError(msg.err)
+			} else {
+				a.statusMsg = fmt.Sprintf("Error: %v", msg.err)
+			}
+			break
+		}
+		a.newChat = nil
+		a.contacts.SetConversations(a.store.GetConversations())
+		a.contacts.SelectConversation(msg.conversation.ID)
+		a.focusPanel(PanelInput)
+		cmds = append(cmds, a.openConversation(msg.conversation))
+
+	case ShowRosterMsg:
+		if conv := a.store.GetConversation(msg.ConversationID); conv != nil {
+			a.roster = conv
+		}
+
+	case DeleteMessageMsg:
+		if msg.Message.IsLocal() && msg.Message.Status == store.StatusPending {
+			a.statusMsg = "Wait for the message to send before deleting it"
+			break
+		}
+		a.confirmDelete = msg.Message
+
+	case messageDeletedMsg:
+		if msg.err != nil {
+			a.statusMsg = fmt.Sprintf("Error: %v", msg.err)
+			break
+		}
+		a.removeDeletedMessage(msg.conversationID, msg.messageID)
+		a.statusMsg = "Message deleted"
+
+	case TypingMsg:
+		cmds = append(cmds, a.sendTyping())
+
+	case typingExpiredMsg:
+		a.refreshTyping()
+
+	case AttachFileMsg:
+		if a.activeConversationID == "" {
+			a.statusMsg = "Select a conversation first! (Enter in contacts)"
+			break
+		}
+		a.input.BeginAttach()
+		a.statusMsg = "Attach a file"
+
+	case SendAttachmentMsg:
+		log.Printf("App: SendAttachmentMsg received for %s", msg.Path)
+		cmds = append(cmds, a.sendAttachment(msg.Path, msg.Caption, msg.SIMID))
+
+	case UploadProgressMsg:
+		a.input.SetUploadProgress(msg.Progress)
+		// Progress arrives through the external message channel
+		cmds = append(cmds, a.listenForExternalMsgs())
 
 	case OpenEditorMsg:
 		return a, StartEditorCmd(a.cfg, msg.InitialContent)
@@ -266,26 +493,166 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.statusMsg = "Press Enter to send"
 		}
 
+	case SendReactionMsg:
+		cmds = append(cmds, a.sendReaction(msg.Message, msg.Emoji))
+
+	case reactionSentMsg:
+		if msg.err != nil {
+			log.Printf("App: SendReaction error: %v", msg.err)
+			a.statusMsg = fmt.Sprintf("Error: %v", msg.err)
+			break
+		}
+		switch msg.action {
+		case client.ReactionRemove:
+			a.statusMsg = "Removed reaction " + msg.emoji
+		case client.ReactionSwitch:
+			a.statusMsg = "Changed reaction to " + msg.emoji
+		default:
+			a.statusMsg = "Reacted " + msg.emoji
+		}
+		// The client updated the cached message, show it
+		if msg.conversationID == a.messages.conversationID {
+			a.messages.SetMessages(msg.conversationID, a.store.GetMessages(msg.conversationID))
+		}
+
+	case OpenMediaMsg:
+		if msg.Message.MediaID == "" && msg.Message.Attachment != "" {
+			// Not uploaded yet, the file is still on disk
+			a.statusMsg = "Opening " + msg.Message.Attachment
+			cmds = append(cmds, OpenFileCmd(msg.Message.Attachment))
+			break
+		}
+		a.statusMsg = "Downloading attachment..."
+		cmds = append(cmds, a.downloadMedia(msg.Message))
+
+	case mediaDownloadedMsg:
+		if msg.err != nil {
+			a.statusMsg = fmt.Sprintf("Error: %v", msg.err)
+			break
+		}
+		msg.message.MediaURL = msg.path
+		a.statusMsg = "Opening " + msg.path
+		cmds = append(cmds, OpenFileCmd(msg.path))
+
+	case MediaOpenedMsg:
+		if msg.Err != nil {
+			a.statusMsg = fmt.Sprintf("Error: %v", msg.Err)
+		} else {
+			a.statusMsg = "Opened " + msg.Path
+		}
+
 	case EditorCancelledMsg:
 		a.statusMsg = "Message cancelled"
 
+	case LoadMoreConversationsMsg:
+		cmds = append(cmds, a.loadNextConversationPage())
+
+	case conversationPageLoadedMsg:
+		a.loadingPage = false
+		if msg.err != nil {
+			log.Printf("App: Failed to load conversation page: %v", msg.err)
+			a.statusMsg = fmt.Sprintf("Error: %v", msg.err)
+			break
+		}
+		a.conversationCursor = msg.nextCursor
+		a.contacts.SetHasMore(msg.nextCursor != "")
+		convs := a.store.GetConversations()
+		a.contacts.SetConversations(convs)
+		a.statusMsg = fmt.Sprintf("Loaded %d conversations", len(convs))
+		// Keep walking the rest of the list in the background
+		if msg.nextCursor != "" {
+			cmds = append(cmds, tea.Tick(backgroundPageDelay, func(time.Time) tea.Msg {
+				return LoadMoreConversationsMsg{}
+			}))
+		}
+
 	case conversationsLoadedMsg:
 		log.Printf("App: Received conversationsLoadedMsg with %d conversations", len(msg.conversations))
 		a.contacts.SetConversations(msg.conversations)
 		a.statusMsg = fmt.Sprintf("Loaded %d conversations", len(msg.conversations))
 
 	case messagesLoadedMsg:
+		// Only the first load knows where older history starts
+		if _, ok := a.olderCursors[msg.conversationID]; !ok {
+			a.olderCursors[msg.conversationID] = msg.olderCursor
+		}
+		a.messages.SetConversation(a.store.GetConversation(msg.conversationID))
 		a.messages.SetMessages(msg.conversationID, msg.messages)
+		a.refreshTyping()
+		a.messages.SetHasOlder(a.olderCursors[msg.conversationID] != "")
+		cmds = append(cmds, a.autoMarkRead(msg.conversationID))
 
-	case messageSentMsg:
-		log.Printf("App: Message sent, refreshing conversation")
-		a.statusMsg = "Message sent"
-		// Clear sending indicator
-		a.input, _ = a.input.Update(MessageSentNotifyMsg{})
-		// Reload active conversation to show the sent message
-		if a.activeConversationID != "" {
-			cmds = append(cmds, a.loadMessages(a.activeConversationID))
+	case ReachedNewestMsg:
+		cmds = append(cmds, a.autoMarkRead(msg.ConversationID))
+
+	case ToggleUnreadMsg:
+		cmds = append(cmds, a.toggleUnread(msg.ConversationID))
+
+	case ToggleArchiveMsg:
+		cmds = append(cmds, a.toggleArchive(msg.ConversationID))
+
+	case conversationArchivedMsg:
+		a.handleArchived(msg)
+
+	case TogglePinMsg:
+		a.togglePin(msg.ConversationID)
+
+	case ToggleMuteMsg:
+		cmds = append(cmds, a.toggleMute(msg.ConversationID))
+
+	case conversationMutedMsg:
+		// The mute already applies here, only the phone missed it
+		if msg.err != nil {
+			verb := "Unmuted"
+			if msg.muted {
+				verb = "Muted"
+			}
+			a.statusMsg = fmt.Sprintf("%s here, but the phone didn't take it: %v", verb, msg.err)
+		}
+
+	case ChooseSIMMsg:
+		a.chooseSIM(msg.ConversationID)
+
+	case ToggleBlockMsg:
+		cmds = append(cmds, a.toggleBlock(msg.ConversationID))
+
+	case conversationBlockedMsg:
+		a.handleBlocked(msg)
+
+	case ShowBlockedMsg:
+		cmds = append(cmds, a.loadBlocked())
+
+	case blockedLoadedMsg:
+		a.handleBlockedLoaded(msg)
+
+	case muteTickMsg:
+		cmds = append(cmds, a.expireMutes(), scheduleMuteCheck())
+
+	case markedReadMsg:
+		if msg.err != nil {
+			log.Printf("App: MarkRead error: %v", msg.err)
+			a.statusMsg = fmt.Sprintf("Error: %v", msg.err)
+			break
+		}
+		a.contacts.SetConversations(a.store.GetConversations())
+
+	case LoadOlderMessagesMsg:
+		cursor := a.olderCursors[msg.ConversationID]
+		if cursor != "" && !a.messages.LoadingOlder() {
+			a.messages.SetLoadingOlder(true)
+			cmds = append(cmds, a.loadOlderMessages(msg.ConversationID, cursor))
+		}
+
+	case olderMessagesLoadedMsg:
+		a.messages.SetLoadingOlder(false)
+		if msg.err != nil {
+			log.Printf("App: Failed to load older messages: %v", msg.err)
+			a.statusMsg = fmt.Sprintf("Error: %v", msg.err)
+			break
 		}
+		a.olderCursors[msg.conversationID] = msg.nextCursor
+		a.messages.PrependMessages(msg.conversationID, msg.messages)
+		a.messages.SetHasOlder(msg.nextCursor != "")
 
 	case qrCodeMsg:
 		log.Printf("App: Received qrCodeMsg, transitioning to QRPairing state")
@@ -294,15 +661,37 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Continue listening for more external messages
 		cmds = append(cmds, a.listenForExternalMsgs())
 
+	case pairingEmojiMsg:
+		log.Printf("App: Received pairingEmojiMsg, transitioning to EmojiPairing state")
+		a.state = StateEmojiPairing
+		a.pairingEmoji = msg.emoji
+		// Continue listening for more external messages
+		cmds = append(cmds, a.listenForExternalMsgs())
+
+	case pairingAccountMsg:
+		log.Printf("App: Received pairingAccountMsg for %s", msg.name)
+		a.pairingAccount = msg.name
+		// The last account's QR code or emoji is no use for this one
+		if a.state == StateQRPairing || a.state == StateEmojiPairing {
+			a.state = StateLoading
+			a.qrURL = ""
+			a.pairingEmoji = ""
+		}
+		// Continue listening for more external messages
+		cmds = append(cmds, a.listenForExternalMsgs())
+
 	case connectedMsg:
 		log.Printf("App: Received connectedMsg, transitioning to Connected state")
-		a.state = StateConnected
-		a.statusMsg = "Connected"
-		a.contacts.SetFocused(true)
-		cmds = append(cmds, a.loadConversations())
+		cmds = append(cmds, a.handleConnected())
 		// Continue listening for more external messages
 		cmds = append(cmds, a.listenForExternalMsgs())
 
+	case reconnectTickMsg:
+		// Keep ticking only while the countdown is visible
+		if a.reconnecting {
+			cmds = append(cmds, reconnectTick())
+		}
+
 	case errorMsg:
 		log.Printf("App: Received errorMsg: %v", msg.err)
 		a.err = msg.err
@@ -350,9 +739,32 @@ func (a *App) View() string {
 		return a.renderLoading()
 	case StateQRPairing:
 		return a.renderQRPairing()
+	case StateEmojiPairing:
+		return a.renderEmojiPairing()
 	case StateError:
 		return a.renderError()
 	case StateConnected:
+		if a.overdue != nil {
+			return a.renderOverdueConfirm()
+		}
+		if a.confirmDelete != nil {
+			return a.renderDeleteConfirm()
+		}
+		if a.newChat != nil {
+			return a.renderNewChat()
+		}
+		if a.roster != nil {
+			return a.renderRoster()
+		}
+		if a.muting != nil {
+			return a.renderMuteChooser()
+		}
+		if a.blocking != nil {
+			return a.renderBlockConfirm()
+		}
+		if a.choosingSIM != nil {
+			return a.renderSIMChooser()
+		}
 		return a.renderConnected()
 	default:
 		return "Unknown state"
@@ -361,19 +773,35 @@ func (a *App) View() string {
 
 // renderLoading renders the loading screen
 func (a *App) renderLoading() string {
+	title := "Connecting to Google Messages..."
+	if a.pairingAccount != "" {
+		title = fmt.Sprintf("Connecting %s to Google Messages...", a.pairingAccount)
+	}
 	return lipgloss.Place(
 		a.width, a.height,
 		lipgloss.Center, lipgloss.Center,
-		a.styles.QRTitle.Render("Connecting to Google Messages..."),
+		a.styles.QRTitle.Render(title),
 	)
 }
 
+// renderPairingAccount names the account being paired under a pairing
+// screen's title, if the backend runs several
+func (a *App) renderPairingAccount(content *strings.Builder) {
+	if a.pairingAccount == "" {
+		return
+	}
+	content.WriteString(a.styles.QRHelp.Render("for profile " + a.pairingAccount))
+	content.WriteString("\n")
+}
+
 // renderQRPairing renders the QR code pairing screen
 func (a *App) renderQRPairing() string {
 	var content strings.Builder
 
 	content.WriteString(a.styles.QRTitle.Render("Scan QR with Google Messages"))
-	content.WriteString("\n\n")
+	content.WriteString("\n")
+	a.renderPairingAccount(&content)
+	content.WriteString("\n")
 
 	// Generate QR code at render time with appropriate size for terminal
 	if a.qrURL != "" {
@@ -484,8 +912,8 @@ func (a *App) renderConnected() string {
 		}
 	}
 
-	// Input takes 3 lines (border + content + border)
-	inputHeight := 3
+	// Input takes 3 lines (border + content + border), plus one while replying
+	inputHeight := a.input.Height()
 	// Messages panel height: content area minus input, minus borders (2 for contacts border overlap)
 	messagesHeight := contentHeight - inputHeight - 2
 
@@ -535,6 +963,17 @@ func (a *App) renderStatusBar() string {
 	if a.statusMsg != "" {
 		left = a.statusMsg
 	}
+	if a.reconnecting {
+		left = a.reconnectStatus()
+	}
+	if warning := a.sessionStatus(); warning != "" {
+		left = warning + " │ " + left
+	}
+	account := a.activeAccount()
+	phone := a.phones[account]
+	if phone.Offline {
+		left = "Phone offline, messages will wait for it │ " + left
+	}
 
 	// Right side: focused panel indicator
 	var panelName string
@@ -547,8 +986,15 @@ func (a *App) renderStatusBar() string {
 		panelName = "[Input]"
 	}
 
+	if indicators := phoneIndicators(phone); indicators != "" {
+		panelName = indicators + "  " + panelName
+	}
+	if account != "" {
+		panelName = "@" + account + "  " + panelName
+	}
+
 	// Calculate spacing
-	spacing := a.width - len(left) - len(panelName) - 2
+	spacing := a.width - lipgloss.Width(left) - lipgloss.Width(panelName) - 2
 	if spacing < 1 {
 		spacing = 1
 	}
@@ -559,6 +1005,9 @@ func (a *App) renderStatusBar() string {
 	if a.leaderKeyPressed {
 		return a.styles.StatusBarLeader.Width(a.width).Render(status)
 	}
+	if phone.Offline || !a.sessionExpiry.IsZero() {
+		return a.styles.StatusBarOffline.Width(a.width).Render(status)
+	}
 	return a.styles.StatusBar.Width(a.width).Render(status)
 }
 
@@ -579,14 +1028,29 @@ func (a *App) renderHelpBar() string {
 	var help string
 	switch a.focusedPanel {
 	case PanelContacts:
-		help = fmt.Sprintf("↑/k ↓/j: navigate | Enter: select | /: search | %s | q: quit", leaderHint)
+		help = fmt.Sprintf("↑/k ↓/j: navigate | Enter: select | /: search | n: new | u: read/unread | a: archive | p: pin | m: mute | b: block | s: SIM | A: archived | S: spam | %s | q: quit", leaderHint)
+		if len(a.accounts) > 1 {
+			help = "@: next account | " + help
+		}
 	case PanelMessages:
-		help = fmt.Sprintf("↑/k ↓/j: scroll | %s | q: quit", leaderHint)
+		if a.messages.Picking() {
+			help = "[REACT] ←/h →/l: choose | 1-9/Enter: react | Esc: cancel"
+		} else {
+			help = fmt.Sprintf("↑/k ↓/j: scroll | r: reply | ctrl+r: react | dd: delete | i: members | o: open attachment | R/X: retry/discard failed | e/X: edit/cancel scheduled | %s | q: quit", leaderHint)
+		}
 	case PanelInput:
-		if a.input.Mode() == ModeNormal {
+		if a.input.Attaching() {
+			help = "[ATTACH] Tab: complete path | Enter: next/send | Esc: cancel"
+		} else if a.input.Scheduling() {
+			help = "[LATER] Enter: schedule | Esc: cancel"
+		} else if a.input.Rescheduling() != nil {
+			help = fmt.Sprintf("[EDIT] Enter: save | Ctrl+T: change time | Esc Esc: cancel | %s", leaderHint)
+		} else if a.input.ReplyingTo() != nil {
+			help = fmt.Sprintf("[REPLY] Enter: send reply | Esc Esc: cancel reply | %s", leaderHint)
+		} else if a.input.Mode() == ModeNormal {
 			help = fmt.Sprintf("[NORMAL] i: insert | v: editor | d: clear | Enter: send | %s", leaderHint)
 		} else {
-			help = fmt.Sprintf("[INSERT] Esc: normal mode | Enter: send | %s", leaderHint)
+			help = fmt.Sprintf("[INSERT] Esc: normal mode | Enter: send | Ctrl+A: attach | Ctrl+T: send later | %s", leaderHint)
 		}
 	default:
 		help = fmt.Sprintf("Tab: switch panel | %s | q: quit", leaderHint)
@@ -615,7 +1079,7 @@ func (a *App) updateSizes() {
 		}
 	}
 
-	inputHeight := 3
+	inputHeight := a.input.Height()
 	messagesHeight := contentHeight - inputHeight - 2
 
 	a.contacts.SetSize(contactsWidth, contentHeight-2)
@@ -752,34 +1216,145 @@ func (a *App) handleConnectedInput(msg tea.KeyMsg) tea.Cmd {
 	// Handle Enter on contacts to select conversation and load messages
 	if a.focusedPanel == PanelContacts && msg.String() == "enter" {
 		if conv := a.contacts.SelectedConversation(); conv != nil {
-			a.activeConversationID = conv.ID
-			a.statusMsg = fmt.Sprintf("Selected: %s", conv.Name)
-			return a.loadMessages(conv.ID)
+			return a.openConversation(conv)
 		}
 	}
 	return nil
 }
 
+// openConversation makes conv the active conversation and loads its messages
+func (a *App) openConversation(conv *store.Conversation) tea.Cmd {
+	if conv.ID != a.activeConversationID {
+		a.input.CancelReply()
+		a.input.CancelSIMChoice()
+	}
+	a.input.SetSIMs(a.accountSIMs(conv.Account))
+	a.input.SetConversationSIM(conv.SendingSIM())
+	a.activeConversationID = conv.ID
+	a.statusMsg = fmt.Sprintf("Selected: %s", conv.Name)
+	// Opening a conversation undoes marking it unread
+	a.store.ClearMarkedUnread(conv.ID)
+	return a.loadMessages(conv.ID)
+}
+
+// handleConnected sets the app up on the first connection. Later ones come
+// with a reconnect event, which catches up on what was missed.
+func (a *App) handleConnected() tea.Cmd {
+	var cmds []tea.Cmd
+	a.state = StateConnected
+	if !a.initialized {
+		a.initialized = true
+		a.statusMsg = "Connected"
+		a.contacts.SetFocused(true)
+		cmds = append(cmds, a.loadFirstConversationPage(), a.fetchContacts())
+		// Ask before sending what was scheduled for while the app was closed
+		a.askOverdue()
+	}
+	// Send what was queued before the app last exited or while offline
+	for _, account := range a.accountNames() {
+		if !a.phones[account].Offline {
+			cmds = append(cmds, a.flushQueue(account))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
 // handleClientEvent handles events from the client
 func (a *App) handleClientEvent(evt client.Event) tea.Cmd {
 	switch evt.Type {
 	case client.EventTypeConnected:
-		return func() tea.Msg { return connectedMsg{} }
+		return a.handleConnected()
 
 	case client.EventTypeDisconnected:
 		a.statusMsg = "Disconnected"
 
+	case client.EventTypeReconnecting:
+		wasReconnecting := a.reconnecting
+		a.reconnecting = true
+		if info, ok := evt.Data.(*client.ReconnectInfo); ok {
+			a.reconnect = *info
+		}
+		if evt.Error != nil {
+			log.Printf("App: connection lost: %v", evt.Error)
+		}
+		// Start the countdown ticker once per outage
+		if !wasReconnecting {
+			return reconnectTick()
+		}
+
+	case client.EventTypeReconnected:
+		a.reconnecting = false
+		a.reconnect = client.ReconnectInfo{}
+		a.statusMsg = "Reconnected"
+		// Catch up on anything missed while offline
+		cmds := []tea.Cmd{a.loadConversations()}
+		if a.activeConversationID != "" {
+			cmds = append(cmds, a.loadMessages(a.activeConversationID))
+		}
+		return tea.Batch(cmds...)
+
 	case client.EventTypeNewMessage:
 		if evt.Message != nil {
+			a.clearTyping(evt.Message.ConversationID, evt.Message.SenderName)
 			a.messages.AddMessage(evt.Message)
-			// Update conversation list
-			return a.loadConversations()
+			// The store already moved the message into its conversation, only
+			// a conversation not seen before needs listing
+			var refresh tea.Cmd
+			if a.store.GetConversation(evt.Message.ConversationID) != nil {
+				a.contacts.SetConversations(a.store.GetConversations())
+			} else {
+				refresh = a.loadConversations()
+			}
+			return tea.Batch(refresh, a.autoMarkRead(evt.Message.ConversationID), a.notify(evt.Message))
+		}
+
+	case client.EventTypeMessageUpdated:
+		// Status changes only touch the message itself
+		if evt.Message != nil {
+			a.messages.UpdateMessage(evt.Message)
+		}
+
+	case client.EventTypeMessageDeleted:
+		if evt.Message != nil {
+			a.removeDeletedMessage(evt.Message.ConversationID, evt.Message.ID)
 		}
 
 	case client.EventTypeConversationsUpdated:
+		// A single changed conversation is already in the store
+		if evt.Conversation != nil {
+			a.contacts.SetConversations(a.store.GetConversations())
+			return nil
+		}
 		return a.loadConversations()
 
+	case client.EventTypeContactsUpdated:
+		a.refreshNames()
+
+	case client.EventTypeSIMsUpdated:
+		a.refreshSIMs()
+
+	case client.EventTypeSessionExpiring:
+		if warning, ok := evt.Data.(*client.SessionWarning); ok {
+			a.sessionExpiry = warning.ExpiresAt
+			a.sessionAccount = warning.Account
+		}
+
+	case client.EventTypeSessionRefreshed:
+		a.sessionExpiry = time.Time{}
+		a.statusMsg = "Session refreshed"
+
+	case client.EventTypePhoneStatus:
+		if status, ok := evt.Data.(*client.PhoneStatus); ok {
+			return a.handlePhoneStatus(*status)
+		}
+
+	case client.EventTypeTypingIndicator:
+		if info, ok := evt.Data.(*client.TypingInfo); ok {
+			return a.handleTypingEvent(info)
+		}
+
 	case client.EventTypeError:
+		a.reconnecting = false
 		if evt.Error != nil {
 			a.statusMsg = fmt.Sprintf("Error: %v", evt.Error)
 		}
@@ -787,6 +1362,25 @@ func (a *App) handleClientEvent(evt client.Event) tea.Cmd {
 	return nil
 }
 
+// reconnectTick schedules the next status bar countdown refresh
+func reconnectTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return reconnectTickMsg{}
+	})
+}
+
+// reconnectStatus describes the reconnect state for the status bar
+func (a *App) reconnectStatus() string {
+	if a.reconnect.Temporary || a.reconnect.RetryAt.IsZero() {
+		return "Connection interrupted, retrying..."
+	}
+	remaining := time.Until(a.reconnect.RetryAt).Round(time.Second)
+	if remaining <= 0 {
+		return fmt.Sprintf("Reconnecting (attempt %d)...", a.reconnect.Attempt)
+	}
+	return fmt.Sprintf("Disconnected, reconnecting in %s (attempt %d)", remaining, a.reconnect.Attempt)
+}
+
 // listenForEvents starts listening for client events
 func (a *App) listenForEvents() tea.Cmd {
 	return func() tea.Msg {
@@ -818,41 +1412,172 @@ func (a *App) loadConversations() tea.Cmd {
 	}
 }
 
+// loadFirstConversationPage starts paging through the conversation list
+func (a *App) loadFirstConversationPage() tea.Cmd {
+	a.loadingPage = true
+	return a.loadConversationPage("")
+}
+
+// loadNextConversationPage fetches the next page unless one is in flight or
+// the whole list has been loaded
+func (a *App) loadNextConversationPage() tea.Cmd {
+	if a.loadingPage || a.conversationCursor == "" {
+		return nil
+	}
+	a.loadingPage = true
+	return a.loadConversationPage(a.conversationCursor)
+}
+
+// loadConversationPage fetches a page of conversations from the client
+func (a *App) loadConversationPage(cursor string) tea.Cmd {
+	return func() tea.Msg {
+		log.Printf("App: Loading conversation page %q...", cursor)
+		page, err := a.client.ListConversationsPage(a.ctx, cursor)
+		if err != nil {
+			return conversationPageLoadedMsg{err: err}
+		}
+		return conversationPageLoadedMsg{nextCursor: page.NextCursor}
+	}
+}
+
 // loadMessages loads messages for a conversation
 func (a *App) loadMessages(conversationID string) tea.Cmd {
 	return func() tea.Msg {
-		msgs, err := a.client.GetMessages(a.ctx, conversationID)
+		page, err := a.client.GetMessagesPage(a.ctx, conversationID, "")
 		if err != nil {
 			return errorMsg{err: err}
 		}
 		return messagesLoadedMsg{
 			conversationID: conversationID,
-			messages:       msgs,
+			messages:       a.store.GetMessages(conversationID),
+			olderCursor:    page.NextCursor,
 		}
 	}
 }
 
-// sendMessage sends a message to the active conversation
-func (a *App) sendMessage(content string) tea.Cmd {
+// loadOlderMessages fetches the page of history before cursor
+func (a *App) loadOlderMessages(conversationID, cursor string) tea.Cmd {
+	return func() tea.Msg {
+		page, err := a.client.GetMessagesPage(a.ctx, conversationID, cursor)
+		if err != nil {
+			return olderMessagesLoadedMsg{conversationID: conversationID, err: err}
+		}
+		return olderMessagesLoadedMsg{
+			conversationID: conversationID,
+			messages:       page.Messages,
+			nextCursor:     page.NextCursor,
+		}
+	}
+}
+
+// sendReaction reacts to a message; choosing the current reaction removes it
+func (a *App) sendReaction(message *store.Message, emoji string) tea.Cmd {
+	action := client.ReactionActionFor(message, emoji)
+	return func() tea.Msg {
+		err := a.client.SendReaction(a.ctx, message.ConversationID, message.ID, emoji, action)
+		return reactionSentMsg{
+			conversationID: message.ConversationID,
+			emoji:          emoji,
+			action:         action,
+			err:            err,
+		}
+	}
+}
+
+// autoMarkRead sends a read receipt for the open conversation once its newest
+// message is selected, unless read receipts are turned off
+func (a *App) autoMarkRead(conversationID string) tea.Cmd {
+	if !a.cfg.Privacy.ReadReceipts || conversationID != a.activeConversationID {
+		return nil
+	}
+	if a.messages.conversationID != conversationID || !a.messages.AtNewest() {
+		return nil
+	}
+	conv := a.store.GetConversation(conversationID)
+	if conv == nil || !conv.Unread || conv.MarkedUnread {
+		return nil
+	}
+	return a.markRead(conversationID)
+}
+
+// toggleUnread marks an unread conversation read, or a read one unread. The
+// phone cannot be told about unread marks, so those stay on this device.
+func (a *App) toggleUnread(conversationID string) tea.Cmd {
+	conv := a.store.GetConversation(conversationID)
+	if conv == nil {
+		return nil
+	}
+	if conv.Unread {
+		cmd := a.markRead(conversationID)
+		if cmd == nil {
+			a.statusMsg = "Open the conversation to mark it read"
+		}
+		return cmd
+	}
+	a.store.MarkConversationUnread(conversationID)
+	a.contacts.SetConversations(a.store.GetConversations())
+	a.statusMsg = "Marked unread on this device"
+	return nil
+}
+
+// markRead marks a conversation read up to its newest loaded message. Returns
+// nil if no messages are loaded yet.
+func (a *App) markRead(conversationID string) tea.Cmd {
+	msgs := a.store.GetMessages(conversationID)
+	if len(msgs) == 0 {
+		return nil
+	}
+	latestID := msgs[len(msgs)-1].ID
+	return func() tea.Msg {
+		err := a.client.MarkRead(a.ctx, conversationID, latestID)
+		return markedReadMsg{conversationID: conversationID, err: err}
+	}
+}
+
+// downloadMedia fetches a message's attachment into the local cache
+func (a *App) downloadMedia(message *store.Message) tea.Cmd {
+	return func() tea.Msg {
+		path, err := a.client.DownloadMedia(a.ctx, message)
+		return mediaDownloadedMsg{message: message, path: path, err: err}
+	}
+}
+
+// sendAttachment queues a file for the active conversation, from the SIM simID
+// or the conversation's if empty. Like text messages, it waits in the outbox
+// while the phone is offline.
+func (a *App) sendAttachment(path, caption, simID string) tea.Cmd {
 	if a.activeConversationID == "" {
-		log.Printf("App: sendMessage - no conversation selected")
 		a.statusMsg = "Select a conversation first! (Enter in contacts)"
-		// Clear sending indicator
 		a.input, _ = a.input.Update(MessageFailedNotifyMsg{})
 		return nil
 	}
 
-	log.Printf("App: Sending message to conversation %s", a.activeConversationID)
-	convID := a.activeConversationID
-	return func() tea.Msg {
-		err := a.client.SendMessage(a.ctx, convID, content)
-		if err != nil {
-			log.Printf("App: SendMessage error: %v", err)
-			return errorMsg{err: err}
-		}
-		log.Printf("App: Message sent successfully")
-		return messageSentMsg{}
+	info, err := client.StatAttachment(path)
+	if err != nil {
+		a.statusMsg = fmt.Sprintf("Error: %v", err)
+		a.input, _ = a.input.Update(MessageFailedNotifyMsg{})
+		return nil
+	}
+
+	msg := &store.Message{
+		ID:             client.NewTmpID(),
+		ConversationID: a.activeConversationID,
+		SenderID:       "me",
+		Content:        caption,
+		Timestamp:      time.Now(),
+		IsFromMe:       true,
+		MediaType:      mime.TypeByExtension(filepath.Ext(path)),
+		MediaName:      filepath.Base(path),
+		MediaSize:      info.Size(),
+		SIMID:          simID,
+		Attachment:     path,
+	}
+	cmd := a.enqueue(msg)
+	if cmd == nil {
+		// Queued until the phone is back, nothing to wait for yet
+		a.input, _ = a.input.Update(MessageSentNotifyMsg{})
 	}
+	return cmd
 }
 
 // Message types for internal communication
@@ -860,28 +1585,96 @@ type conversationsLoadedMsg struct {
 	conversations []*store.Conversation
 }
 
+type conversationPageLoadedMsg struct {
+	nextCursor string
+	err        error
+}
+
 type messagesLoadedMsg struct {
 	conversationID string
 	messages       []*store.Message
+	olderCursor    string
+}
+
+type olderMessagesLoadedMsg struct {
+	conversationID string
+	messages       []*store.Message
+	nextCursor     string
+	err            error
+}
+
+type mediaDownloadedMsg struct {
+	message *store.Message
+	path    string
+	err     error
+}
+
+type reactionSentMsg struct {
+	conversationID string
+	emoji          string
+	action         client.ReactionAction
+	err            error
+}
+
+// renderEmojiPairing renders the Google account pairing screen
+func (a *App) renderEmojiPairing() string {
+	var content strings.Builder
+
+	content.WriteString(a.styles.QRTitle.Render("Confirm on your phone"))
+	content.WriteString("\n")
+	a.renderPairingAccount(&content)
+	content.WriteString("\n")
+	content.WriteString(a.styles.QRHelp.Render("Google Messages on your phone is asking"))
+	content.WriteString("\n")
+	content.WriteString(a.styles.QRHelp.Render("which emoji this computer shows. Tap this one:"))
+	content.WriteString("\n\n")
+	content.WriteString(lipgloss.NewStyle().Width(46).Align(lipgloss.Center).Render(a.pairingEmoji))
+	content.WriteString("\n\n")
+	content.WriteString(a.styles.QRHelp.Render("Nothing on the phone? Open Google Messages and check"))
+	content.WriteString("\n")
+	content.WriteString(a.styles.QRHelp.Render("it uses the Google account the cookies came from"))
+
+	box := a.styles.QRContainer.Render(content.String())
+
+	return lipgloss.Place(
+		a.width, a.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
+
+type markedReadMsg struct {
+	conversationID string
+	err            error
 }
 
 type qrCodeMsg struct {
 	url string
 }
 
+type pairingEmojiMsg struct {
+	emoji string
+}
+
 type connectedMsg struct{}
 
 type errorMsg struct {
 	err error
 }
 
-type messageSentMsg struct{}
+type reconnectTickMsg struct{}
 
 // SetQRCode sends a QR code URL to the app through the message channel
 func (a *App) SetQRCode(url string) {
 	a.externalMsgs <- qrCodeMsg{url: url}
 }
 
+// SetPairingEmoji sends the emoji to tap on the phone to the app through
+// the message channel
+func (a *App) SetPairingEmoji(emoji string) {
+	a.externalMsgs <- pairingEmojiMsg{emoji: emoji}
+}
+
 // SetConnected sends a connected message to the app through the message channel
 func (a *App) SetConnected() {
 	a.externalMsgs <- connectedMsg{}
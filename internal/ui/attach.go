@@ -0,0 +1,227 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/n0ko/messages-tui/internal/client"
+)
+
+// AttachStage is the step of the attach flow the input is in
+type AttachStage int
+
+const (
+	AttachNone    AttachStage = iota
+	AttachPath                // Entering the path of the file to attach
+	AttachCaption             // Entering an optional caption
+)
+
+// SendAttachmentMsg is sent when the user confirms an attachment to send
+type SendAttachmentMsg struct {
+	Path    string
+	Caption string
+	SIMID   string // SIM picked for this message, empty for the conversation's
+}
+
+// UploadProgressMsg reports attachment upload progress to the input bar
+type UploadProgressMsg struct {
+	Progress client.UploadProgress
+}
+
+// BeginAttach switches the input to the attach flow, keeping the current
+// draft to restore afterwards
+func (m *InputModel) BeginAttach() {
+	m.savedDraft = m.draftContent
+	m.savedValue = m.textInput.Value()
+	m.draftContent = ""
+	m.textInput.Reset()
+
+	m.attachStage = AttachPath
+	m.attachPath = ""
+	m.attachHint = ""
+	m.mode = ModeInsert
+	m.textInput.Focus()
+}
+
+// endAttach leaves the attach flow and restores the saved draft
+func (m InputModel) endAttach() InputModel {
+	m.attachStage = AttachNone
+	m.attachPath = ""
+	m.attachHint = ""
+	m.draftContent = m.savedDraft
+	m.textInput.SetValue(m.savedValue)
+	m.savedDraft = ""
+	m.savedValue = ""
+	return m
+}
+
+// Attaching returns whether the input is in the attach flow
+func (m InputModel) Attaching() bool {
+	return m.attachStage != AttachNone
+}
+
+// handleAttachInput handles keys while picking a file or typing its caption
+func (m InputModel) handleAttachInput(msg tea.KeyMsg) (InputModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEscape:
+		return m.endAttach(), nil
+
+	case tea.KeyTab:
+		if m.attachStage == AttachPath {
+			completed, candidates := completePath(m.textInput.Value())
+			m.textInput.SetValue(completed)
+			m.textInput.CursorEnd()
+			m.attachHint = ""
+			if len(candidates) > 1 {
+				m.attachHint = fmt.Sprintf("%d matches", len(candidates))
+			} else if len(candidates) == 0 {
+				m.attachHint = "no match"
+			}
+		}
+		return m, nil
+
+	case tea.KeyEnter:
+		if m.attachStage == AttachPath {
+			path := expandHome(strings.TrimSpace(m.textInput.Value()))
+			info, err := os.Stat(path)
+			if err != nil {
+				m.attachHint = "no such file"
+				return m, nil
+			}
+			if info.IsDir() {
+				m.attachHint = "is a directory"
+				return m, nil
+			}
+			if info.Size() > client.MaxAttachmentSize {
+				m.attachHint = "too large, at most " + formatSize(client.MaxAttachmentSize)
+				return m, nil
+			}
+			m.attachPath = path
+			m.attachHint = formatSize(info.Size())
+			m.attachStage = AttachCaption
+			m.textInput.Reset()
+			return m, nil
+		}
+
+		// Caption entered, send the attachment
+		attachment := SendAttachmentMsg{
+			Path:    m.attachPath,
+			Caption: strings.TrimSpace(m.textInput.Value()),
+			SIMID:   m.takeSIM(),
+		}
+		m = m.endAttach()
+		m.sending = true
+		return m, func() tea.Msg {
+			return attachment
+		}
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	if m.attachStage == AttachPath {
+		m.attachHint = ""
+	}
+	return m, cmd
+}
+
+// attachPrompt returns the mode indicator and placeholder for the attach flow
+func (m InputModel) attachPrompt() (indicator, placeholder string) {
+	if m.attachStage == AttachCaption {
+		return "[Caption] ", "Optional caption for " + filepath.Base(m.attachPath) + " (Enter sends, Esc cancels)"
+	}
+	return "[Attach] ", "Path to file (Tab completes, Esc cancels)"
+}
+
+// SetUploadProgress updates the upload indicator
+func (m *InputModel) SetUploadProgress(p client.UploadProgress) {
+	m.upload = &p
+}
+
+// uploadIndicator describes the upload in progress for the input bar
+func (m InputModel) uploadIndicator() string {
+	p := m.upload
+	size := ""
+	if p.Size > 0 {
+		size = " " + formatSize(p.Size)
+	}
+	if p.Stage == client.UploadStageReading && p.Size > 0 {
+		size += fmt.Sprintf(" %d%%", p.Read*100/p.Size)
+	}
+	return fmt.Sprintf(" %s %s%s (%d/%d)...", uploadStageLabel(p.Stage), p.FileName, size, p.Step, p.Steps)
+}
+
+// uploadStageLabel returns the label shown for an upload stage
+func uploadStageLabel(stage string) string {
+	switch stage {
+	case client.UploadStageReading:
+		return "Reading"
+	case client.UploadStageUploading:
+		return "Uploading"
+	default:
+		return "Sending"
+	}
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + path[1:]
+}
+
+// completePath completes the last element of a file path as far as it is
+// unambiguous and returns every candidate that matched
+func completePath(input string) (string, []string) {
+	if input == "~" {
+		input = "~/"
+	}
+
+	dir, prefix := filepath.Split(expandHome(input))
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return input, nil
+	}
+
+	var matches []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		// Hide dotfiles unless asked for
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if e.IsDir() {
+			name += "/"
+		}
+		matches = append(matches, name)
+	}
+	if len(matches) == 0 {
+		return input, nil
+	}
+
+	common := matches[0]
+	for _, name := range matches[1:] {
+		for !strings.HasPrefix(name, common) {
+			common = common[:len(common)-1]
+		}
+	}
+
+	// Keep the user's spelling of the directory part, e.g. a leading ~
+	return input[:len(input)-len(prefix)] + common, matches
+}
@@ -20,6 +20,16 @@ type ContactsKeyMap struct {
 	Bottom  key.Binding
 	Select  key.Binding
 	Search  key.Binding
+	ToggleUnread key.Binding
+	New          key.Binding
+	Archive      key.Binding
+	Pin          key.Binding
+	Mute         key.Binding
+	ShowArchived key.Binding
+	Block        key.Binding
+	ShowBlocked  key.Binding
+	ChooseSIM    key.Binding
+	NextAccount  key.Binding
 }
 
 // DefaultContactsKeyMap returns the default key bindings
@@ -49,6 +59,46 @@ func DefaultContactsKeyMap() ContactsKeyMap {
 			key.WithKeys("/"),
 			key.WithHelp("/", "search"),
 		),
+		ToggleUnread: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "mark read/unread"),
+		),
+		New: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "new conversation"),
+		),
+		Archive: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "archive/unarchive"),
+		),
+		Pin: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "pin/unpin"),
+		),
+		Mute: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "mute/unmute"),
+		),
+		ShowArchived: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "archived conversations"),
+		),
+		Block: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", "block/unblock"),
+		),
+		ShowBlocked: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "spam & blocked conversations"),
+		),
+		ChooseSIM: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "choose SIM"),
+		),
+		NextAccount: key.NewBinding(
+			key.WithKeys("@"),
+			key.WithHelp("@", "next account"),
+		),
 	}
 }
 
@@ -65,8 +115,69 @@ type ContactsModel struct {
 	searchMode    bool
 	searchQuery   string
 	lastKeyWasG   bool // Track if last key was 'g' for gg combo
+	hasMore       bool // More conversation pages can be fetched
+	typing        map[string]string // Typing labels keyed by conversation ID
+	folder        folder            // Folder being listed
+	sims          []store.SIM       // The phone's SIMs
+	accounts      []string          // Accounts conversations are grouped by, when there are several
+}
+
+// folder is one of the phone's lists of conversations
+type folder int
+
+const (
+	folderInbox folder = iota
+	folderArchive
+	folderSpam // Spam & blocked
+)
+
+// conversationFolder returns the folder a conversation is listed in
+func conversationFolder(conv *store.Conversation) folder {
+	switch {
+	case conv.Blocked:
+		return folderSpam
+	case conv.Archived:
+		return folderArchive
+	default:
+		return folderInbox
+	}
+}
+
+// loadMoreThreshold is how close to the bottom the selection gets before the
+// next page of conversations is requested
+const loadMoreThreshold = 5
+
+// LoadMoreConversationsMsg is sent when the next page of conversations is needed
+type LoadMoreConversationsMsg struct{}
+
+// ToggleUnreadMsg is sent when the user marks a conversation read or unread
+type ToggleUnreadMsg struct {
+	ConversationID string
+}
+
+// ToggleArchiveMsg is sent when the user archives or unarchives a conversation
+type ToggleArchiveMsg struct {
+	ConversationID string
+}
+
+// TogglePinMsg is sent when the user pins or unpins a conversation
+type TogglePinMsg struct {
+	ConversationID string
+}
+
+// ToggleMuteMsg is sent when the user mutes or unmutes a conversation
+type ToggleMuteMsg struct {
+	ConversationID string
 }
 
+// ToggleBlockMsg is sent when the user blocks or unblocks a conversation
+type ToggleBlockMsg struct {
+	ConversationID string
+}
+
+// ShowBlockedMsg is sent when the user opens the spam & blocked folder
+type ShowBlockedMsg struct{}
+
 // NewContactsModel creates a new contacts panel model
 func NewContactsModel(styles *Styles) ContactsModel {
 	return ContactsModel{
@@ -119,33 +230,126 @@ func (m ContactsModel) Update(msg tea.Msg) (ContactsModel, tea.Cmd) {
 			}
 
 		case key.Matches(msg, m.keyMap.Down):
-			if m.selected < len(m.conversations)-1 {
+			if m.selected < len(m.getFilteredConversations())-1 {
 				m.selected++
 				visibleItems := m.visibleItemCount()
 				if m.selected >= m.offset+visibleItems {
 					m.offset = m.selected - visibleItems + 1
 				}
 			}
+			return m, m.loadMoreIfNeeded()
 
 		case key.Matches(msg, m.keyMap.Bottom):
 			// G - go to bottom
-			if len(m.conversations) > 0 {
-				m.selected = len(m.conversations) - 1
+			if n := len(m.getFilteredConversations()); n > 0 {
+				m.selected = n - 1
 				visibleItems := m.visibleItemCount()
 				if m.selected >= visibleItems {
 					m.offset = m.selected - visibleItems + 1
 				}
 			}
+			return m, m.loadMoreIfNeeded()
 
 		case key.Matches(msg, m.keyMap.Search):
 			m.searchMode = true
 			m.searchQuery = ""
+
+		case key.Matches(msg, m.keyMap.New):
+			return m, func() tea.Msg {
+				return NewConversationMsg{}
+			}
+
+		case key.Matches(msg, m.keyMap.ToggleUnread):
+			if conv := m.SelectedConversation(); conv != nil {
+				convID := conv.ID
+				return m, func() tea.Msg {
+					return ToggleUnreadMsg{ConversationID: convID}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.Archive):
+			if conv := m.SelectedConversation(); conv != nil {
+				convID := conv.ID
+				return m, func() tea.Msg {
+					return ToggleArchiveMsg{ConversationID: convID}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.Pin):
+			if conv := m.SelectedConversation(); conv != nil {
+				convID := conv.ID
+				return m, func() tea.Msg {
+					return TogglePinMsg{ConversationID: convID}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.Mute):
+			if conv := m.SelectedConversation(); conv != nil {
+				convID := conv.ID
+				return m, func() tea.Msg {
+					return ToggleMuteMsg{ConversationID: convID}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.Block):
+			if conv := m.SelectedConversation(); conv != nil {
+				convID := conv.ID
+				return m, func() tea.Msg {
+					return ToggleBlockMsg{ConversationID: convID}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.ChooseSIM):
+			if conv := m.SelectedConversation(); conv != nil {
+				convID := conv.ID
+				return m, func() tea.Msg {
+					return ChooseSIMMsg{ConversationID: convID}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.ShowArchived):
+			m.switchFolder(folderArchive)
+
+		case key.Matches(msg, m.keyMap.ShowBlocked):
+			m.switchFolder(folderSpam)
+			if m.folder == folderSpam {
+				return m, func() tea.Msg {
+					return ShowBlockedMsg{}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.NextAccount):
+			m.selectNextAccount()
 		}
 	}
 
 	return m, nil
 }
 
+// switchFolder lists the given folder, or the inbox if it is already listed
+func (m *ContactsModel) switchFolder(f folder) {
+	if m.folder == f {
+		f = folderInbox
+	}
+	m.folder = f
+	m.searchQuery = ""
+	m.selected = 0
+	m.offset = 0
+}
+
+// loadMoreIfNeeded requests the next page when the selection nears the bottom
+func (m ContactsModel) loadMoreIfNeeded() tea.Cmd {
+	if !m.hasMore || m.searchQuery != "" {
+		return nil
+	}
+	if m.selected < len(m.getFilteredConversations())-loadMoreThreshold {
+		return nil
+	}
+	return func() tea.Msg {
+		return LoadMoreConversationsMsg{}
+	}
+}
+
 // handleSearchInput handles input when in search mode
 func (m ContactsModel) handleSearchInput(msg tea.KeyMsg) (ContactsModel, tea.Cmd) {
 	switch msg.Type {
@@ -208,6 +412,12 @@ func (m ContactsModel) View() string {
 
 	// Title
 	title := "Conversations"
+	switch m.folder {
+	case folderArchive:
+		title = "Archived"
+	case folderSpam:
+		title = "Spam & blocked"
+	}
 	titleStyle := m.styles.PanelTitleText
 	b.WriteString(titleStyle.Render(title))
 	b.WriteString("\n")
@@ -226,6 +436,10 @@ func (m ContactsModel) View() string {
 	for i := m.offset; i < len(conversations) && linesUsed < availableHeight; i++ {
 		conv := conversations[i]
 		item := m.renderConversationItem(conv, i == m.selected)
+		// Head each account's conversations with its name
+		if len(m.accounts) > 1 && m.searchQuery == "" && (i == m.offset || conv.Account != conversations[i-1].Account) {
+			item = m.styles.ContactTime.Render("── "+conv.Account+" ──") + "\n" + item
+		}
 		itemLines := strings.Count(item, "\n") + 1
 		if linesUsed+itemLines > availableHeight {
 			break
@@ -279,7 +493,16 @@ func (m ContactsModel) renderSearchBar() string {
 	}
 
 	// Inactive - show hint
-	hint := "/ to search"
+	hint := "/ to search · A: archived · S: spam"
+	switch m.folder {
+	case folderArchive:
+		hint = "/ to search · A: inbox"
+	case folderSpam:
+		hint = "/ to search · S: inbox · b: unblock"
+	}
+	if m.hasMore {
+		hint += " · more below"
+	}
 	return m.styles.ContactPreview.Render(hint)
 }
 
@@ -305,6 +528,13 @@ func (m ContactsModel) renderConversationItem(conv *store.Conversation, selected
 		unreadMark = "● "
 		name = unreadMark + name
 	}
+	muted := conv.IsMuted(time.Now())
+	if muted {
+		name = "🔕 " + name
+	}
+	if conv.Pinned {
+		name = "📌 " + name
+	}
 
 	if len(name) > maxWidth-8 {
 		name = name[:maxWidth-11] + "..."
@@ -338,25 +568,53 @@ func (m ContactsModel) renderConversationItem(conv *store.Conversation, selected
 	if conv.Unread {
 		nameStyle = m.styles.ContactUnread
 	}
+	if muted {
+		nameStyle = m.styles.ContactMuted
+	}
+
+	// Which SIM the conversation sends from, if the phone has several
+	simLabel := ""
+	sim := findSIM(m.sims, conv.SendingSIM())
+	if sim != nil {
+		simLabel = sim.Short() + " "
+	}
 
 	// Calculate spacing between name and time
-	spacing := maxWidth - len(name) - len(timeStr)
+	spacing := maxWidth - len(name) - len(simLabel) - len(timeStr)
 	if spacing < 1 {
 		spacing = 1
 	}
 
-	firstLine := indicator + nameStyle.Render(name) + strings.Repeat(" ", spacing) + m.styles.ContactTime.Render(timeStr)
+	firstLine := indicator + nameStyle.Render(name) + strings.Repeat(" ", spacing) + simStyle(m.styles.ContactTime, sim).Render(simLabel) + m.styles.ContactTime.Render(timeStr)
 
-	// Second line: preview (indented to align with name)
+	// Second line: preview (indented to align with name), or who is typing
 	secondLine := "  " + m.styles.ContactPreview.Render(preview)
+	if label := m.typing[conv.ID]; label != "" {
+		if len(label) > maxWidth-2 {
+			label = "typing…"
+		}
+		secondLine = "  " + m.styles.Typing.Render(label)
+	}
 
 	return itemStyle.Width(m.width - 2).Render(firstLine + "\n" + secondLine)
 }
 
+// folderConversations returns the conversations of the folder being shown
+func (m ContactsModel) folderConversations() []*store.Conversation {
+	convs := make([]*store.Conversation, 0, len(m.conversations))
+	for _, conv := range m.conversations {
+		if conversationFolder(conv) == m.folder {
+			convs = append(convs, conv)
+		}
+	}
+	m.groupByAccount(convs)
+	return convs
+}
+
 // getFilteredConversations returns conversations filtered by search query using fuzzy matching
 func (m ContactsModel) getFilteredConversations() []*store.Conversation {
 	if m.searchQuery == "" {
-		return m.conversations
+		return m.folderConversations()
 	}
 
 	query := strings.ToLower(m.searchQuery)
@@ -368,9 +626,9 @@ func (m ContactsModel) getFilteredConversations() []*store.Conversation {
 	}
 
 	var results []scored
-	for _, conv := range m.conversations {
+	for _, conv := range m.folderConversations() {
 		name := strings.ToLower(conv.Name)
-		score := fuzzyMatch(query, name)
+		score := max(fuzzyMatch(query, name), m.participantScore(query, conv))
 		if score > 0 {
 			results = append(results, scored{conv: conv, score: score})
 		}
@@ -393,6 +651,26 @@ func (m ContactsModel) getFilteredConversations() []*store.Conversation {
 	return filtered
 }
 
+// participantScore scores how well the query matches the names or numbers
+// of a conversation's participants, so groups are found by member
+func (m ContactsModel) participantScore(query string, conv *store.Conversation) int {
+	digits := ""
+	if strings.Trim(query, "+0123456789 -()") == "" {
+		digits = normalizeNumber(query)
+	}
+
+	best := 0
+	for _, p := range conv.Others() {
+		if p.Name != "" {
+			best = max(best, fuzzyMatch(query, strings.ToLower(p.Name)))
+		}
+		if digits != "" && strings.Contains(normalizeNumber(p.Number), digits) {
+			best = max(best, 500+len(digits))
+		}
+	}
+	return best
+}
+
 // fuzzyMatch returns a score for how well the query matches the target
 // Higher scores are better matches, 0 means no match
 func fuzzyMatch(query, target string) int {
@@ -456,11 +734,21 @@ func (m ContactsModel) visibleItemCount() int {
 // SetConversations updates the conversation list
 func (m *ContactsModel) SetConversations(convs []*store.Conversation) {
 	m.conversations = convs
-	if m.selected >= len(convs) {
-		m.selected = max(0, len(convs)-1)
+	if n := len(m.getFilteredConversations()); m.selected >= n {
+		m.selected = max(0, n-1)
 	}
 }
 
+// SetHasMore records whether more conversation pages can be fetched
+func (m *ContactsModel) SetHasMore(hasMore bool) {
+	m.hasMore = hasMore
+}
+
+// SetTyping sets the typing labels shown instead of message previews
+func (m *ContactsModel) SetTyping(labels map[string]string) {
+	m.typing = labels
+}
+
 // SetSize sets the panel dimensions
 func (m *ContactsModel) SetSize(width, height int) {
 	m.width = width
@@ -472,6 +760,25 @@ func (m *ContactsModel) SetFocused(focused bool) {
 	m.focused = focused
 }
 
+// SelectConversation clears the search and selects a conversation by ID,
+// switching to its folder
+func (m *ContactsModel) SelectConversation(id string) {
+	m.searchMode = false
+	m.searchQuery = ""
+	for _, conv := range m.conversations {
+		if conv.ID == id {
+			m.folder = conversationFolder(conv)
+		}
+	}
+	for i, conv := range m.folderConversations() {
+		if conv.ID == id {
+			m.selected = i
+			m.offset = max(0, i-m.visibleItemCount()+1)
+			return
+		}
+	}
+}
+
 // SelectedConversation returns the currently selected conversation
 func (m ContactsModel) SelectedConversation() *store.Conversation {
 	convs := m.getFilteredConversations()
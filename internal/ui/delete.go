@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// DeleteMessageMsg is sent when the user asks to delete the selected message
+type DeleteMessageMsg struct {
+	Message *store.Message
+}
+
+// messageDeletedMsg reports the result of deleting a message
+type messageDeletedMsg struct {
+	conversationID string
+	messageID      string
+	err            error
+}
+
+// handleDeleteConfirmKey answers the delete confirmation dialog
+func (a *App) handleDeleteConfirmKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "y", "enter":
+		target := a.confirmDelete
+		a.confirmDelete = nil
+		// Unsent messages only exist here
+		if target.IsLocal() {
+			a.discardMessage(target)
+			return nil
+		}
+		a.statusMsg = "Deleting message..."
+		return a.deleteMessage(target)
+
+	case "n", "esc", "q":
+		a.confirmDelete = nil
+		a.statusMsg = ""
+	}
+	return nil
+}
+
+// deleteMessage deletes a message on the phone
+func (a *App) deleteMessage(message *store.Message) tea.Cmd {
+	return func() tea.Msg {
+		err := a.client.DeleteMessage(a.ctx, message.ConversationID, message.ID)
+		if err != nil {
+			log.Printf("App: DeleteMessage error: %v", err)
+		}
+		return messageDeletedMsg{
+			conversationID: message.ConversationID,
+			messageID:      message.ID,
+			err:            err,
+		}
+	}
+}
+
+// removeDeletedMessage drops a deleted message from the panels
+func (a *App) removeDeletedMessage(conversationID, messageID string) {
+	if conversationID == a.messages.conversationID {
+		a.messages.RemoveMessage(messageID)
+	}
+	a.contacts.SetConversations(a.store.GetConversations())
+}
+
+// renderDeleteConfirm renders the delete confirmation dialog
+func (a *App) renderDeleteConfirm() string {
+	width := min(60, max(20, a.width-10))
+	content := lipgloss.JoinVertical(
+		lipgloss.Center,
+		a.styles.DialogTitle.Render("Delete message?"),
+		a.styles.MessageQuote.Render(fmt.Sprintf("%s: %s", quoteSender(a.confirmDelete), messageSnippet(a.confirmDelete, width))),
+		"",
+		a.styles.MessageStatus.Render("It will be deleted on your phone too."),
+		"",
+		lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			a.styles.DialogButton.Render("y: delete"),
+			"  ",
+			a.styles.DialogButton.Render("n: cancel"),
+		),
+	)
+
+	box := a.styles.Dialog.Render(content)
+
+	return lipgloss.Place(
+		a.width, a.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/n0ko/messages-tui/internal/client"
+	"github.com/n0ko/messages-tui/internal/store"
 )
 
 // InputMode represents the current mode of the input (like vim)
@@ -23,6 +27,7 @@ const (
 type InputKeyMap struct {
 	Send       key.Binding
 	AttachFile key.Binding
+	SendLater  key.Binding
 }
 
 // DefaultInputKeyMap returns the default key bindings
@@ -36,12 +41,18 @@ func DefaultInputKeyMap() InputKeyMap {
 			key.WithKeys("ctrl+a"),
 			key.WithHelp("ctrl+a", "attach"),
 		),
+		SendLater: key.NewBinding(
+			key.WithKeys("ctrl+t"),
+			key.WithHelp("ctrl+t", "send later"),
+		),
 	}
 }
 
 // SendMessageMsg is sent when the user wants to send a message
 type SendMessageMsg struct {
-	Content string
+	Content   string
+	ReplyToID string // message being replied to, if any
+	SIMID     string // SIM picked for this message, empty for the conversation's
 }
 
 // OpenEditorMsg is sent when the user wants to open the external editor
@@ -71,11 +82,29 @@ type InputModel struct {
 	focused       bool
 	styles        *Styles
 	keyMap        InputKeyMap
-	sending       bool          // Show "Sending..." indicator
-	mode          InputMode     // Current vim mode (insert/normal)
-	pendingAction PendingAction // Pending vim command waiting for char
-	lastFindChar  byte          // Last character used with f/F
-	lastFindDir   int           // 1 = forward (f), -1 = backward (F)
+	sending       bool           // Show "Sending..." indicator
+	mode          InputMode      // Current vim mode (insert/normal)
+	pendingAction PendingAction  // Pending vim command waiting for char
+	lastFindChar  byte           // Last character used with f/F
+	lastFindDir   int            // 1 = forward (f), -1 = backward (F)
+	lastTyping    time.Time      // When the last typing notification was requested
+	replyTo       *store.Message // Message being replied to, if any
+	sims          []store.SIM    // The phone's SIMs
+	sim           string         // SIM the open conversation sends from
+	simChoice     string         // SIM picked for the next message only
+
+	// Attach flow (Ctrl+A)
+	attachStage AttachStage
+	attachPath  string
+	attachHint  string                 // Completion or validation feedback
+	savedDraft  string                 // draftContent before the attach or send later flow
+	savedValue  string                 // textInput value before the attach or send later flow
+	upload      *client.UploadProgress // Upload in progress, if any
+
+	// Send later flow (Ctrl+T)
+	scheduling   bool           // Asking when to send the draft
+	scheduleHint string         // Why the time given wasn't understood
+	rescheduling *store.Message // Scheduled message being edited, if any
 }
 
 // NewInputModel creates a new input model
@@ -112,13 +141,21 @@ func (m InputModel) Update(msg tea.Msg) (InputModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case MessageSentNotifyMsg:
 		m.sending = false
+		m.upload = nil
 		return m, nil
 
 	case MessageFailedNotifyMsg:
 		m.sending = false
+		m.upload = nil
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.focused && m.attachStage != AttachNone {
+			return m.handleAttachInput(msg)
+		}
+		if m.focused && m.scheduling {
+			return m.handleScheduleInput(msg)
+		}
 		if m.focused {
 			log.Printf("Input: KeyMsg received, key=%q, mode=%d (0=insert, 1=normal)", msg.String(), m.mode)
 			// Handle mode-specific keys
@@ -156,13 +193,19 @@ func (m InputModel) handleInsertMode(msg tea.KeyMsg) (InputModel, tea.Cmd) {
 			content = strings.TrimSpace(m.textInput.Value())
 		}
 		log.Printf("Input: Final content=%q", content)
+		// Editing a scheduled message keeps its time
+		if content != "" && m.rescheduling != nil {
+			return m.takeScheduled(m.rescheduling.Timestamp)
+		}
 		if content != "" {
 			m.textInput.Reset()
 			m.draftContent = ""
 			m.sending = true
+			m.lastTyping = time.Time{}
+			replyToID, simID := m.takeReply(), m.takeSIM()
 			log.Printf("Input: Sending message with content length %d", len(content))
 			return m, func() tea.Msg {
-				return SendMessageMsg{Content: content}
+				return SendMessageMsg{Content: content, ReplyToID: replyToID, SIMID: simID}
 			}
 		}
 		log.Printf("Input: No content to send")
@@ -173,12 +216,36 @@ func (m InputModel) handleInsertMode(msg tea.KeyMsg) (InputModel, tea.Cmd) {
 		return m, func() tea.Msg {
 			return AttachFileMsg{}
 		}
+
+	case tea.KeyCtrlS:
+		// Send the next message from another SIM
+		m.cycleSIM()
+		return m, nil
+
+	case tea.KeyCtrlT:
+		// Send later
+		m.BeginSchedule()
+		return m, nil
 	}
 
 	// Let textinput handle other keys
 	var cmd tea.Cmd
+	before := m.textInput.Value()
 	m.textInput, cmd = m.textInput.Update(msg)
-	return m, cmd
+	return m, tea.Batch(cmd, m.typingNotification(before))
+}
+
+// typingNotification requests a typing notification if the text changed,
+// at most once per typingInterval
+func (m *InputModel) typingNotification(before string) tea.Cmd {
+	value := m.textInput.Value()
+	if value == before || value == "" || time.Since(m.lastTyping) < typingInterval {
+		return nil
+	}
+	m.lastTyping = time.Now()
+	return func() tea.Msg {
+		return TypingMsg{}
+	}
 }
 
 // handleNormalMode handles keys in normal mode (vim-like)
@@ -326,19 +393,26 @@ func (m InputModel) handleNormalMode(msg tea.KeyMsg) (InputModel, tea.Cmd) {
 		if content == "" {
 			content = strings.TrimSpace(m.textInput.Value())
 		}
+		if content != "" && m.rescheduling != nil {
+			return m.takeScheduled(m.rescheduling.Timestamp)
+		}
 		if content != "" {
 			m.textInput.Reset()
 			m.draftContent = ""
 			m.sending = true
+			replyToID, simID := m.takeReply(), m.takeSIM()
 			return m, func() tea.Msg {
-				return SendMessageMsg{Content: content}
+				return SendMessageMsg{Content: content, ReplyToID: replyToID, SIMID: simID}
 			}
 		}
 		return m, nil
 
 	case "esc":
-		// Cancel any pending action
-		m.pendingAction = PendingNone
+		// Nothing is pending here, so Esc cancels a reply or an edit
+		m.replyTo = nil
+		if m.rescheduling != nil {
+			m.CancelReschedule()
+		}
 		return m, nil
 	}
 
@@ -624,7 +698,15 @@ func (m InputModel) View() string {
 
 	// Mode indicator and placeholder
 	var modeIndicator string
-	if m.mode == ModeNormal {
+	if m.attachStage != AttachNone {
+		indicator, placeholder := m.attachPrompt()
+		modeIndicator = m.styles.MessageMedia.Bold(true).Render(indicator)
+		m.textInput.Placeholder = placeholder
+	} else if m.scheduling {
+		indicator, placeholder := m.schedulePrompt()
+		modeIndicator = m.styles.MessageMedia.Bold(true).Render(indicator)
+		m.textInput.Placeholder = placeholder
+	} else if m.mode == ModeNormal {
 		modeIndicator = m.styles.ContactUnread.Render("[N] ")
 		m.textInput.Placeholder = "'i' for insert mode"
 	} else {
@@ -636,8 +718,16 @@ func (m InputModel) View() string {
 
 	// Show sending indicator or mode
 	rightIndicator := ""
-	if m.sending {
+	if m.sending && m.upload != nil {
+		rightIndicator = m.styles.ContactUnread.Render(m.uploadIndicator())
+	} else if m.sending {
 		rightIndicator = m.styles.ContactUnread.Render(" Sending...")
+	} else if m.attachHint != "" {
+		rightIndicator = m.styles.ContactPreview.Render(" " + m.attachHint)
+	} else if m.scheduleHint != "" {
+		rightIndicator = m.styles.MessageStatusFailed.Render(" " + m.scheduleHint)
+	} else {
+		rightIndicator = m.simView()
 	}
 
 	// Calculate spacing for right-aligned indicator
@@ -648,6 +738,11 @@ func (m InputModel) View() string {
 	}
 
 	fullView := modeIndicator + inputView + spacing + rightIndicator
+	if m.rescheduling != nil {
+		fullView = m.rescheduleView() + "\n" + fullView
+	} else if m.replyTo != nil {
+		fullView = m.replyView() + "\n" + fullView
+	}
 
 	return style.Width(m.width).Render(fullView)
 }
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// OpenMediaMsg is sent when the user wants to open a message's attachment
+type OpenMediaMsg struct {
+	Message *store.Message
+}
+
+// MediaOpenedMsg is sent after an attachment was handed to the system viewer
+type MediaOpenedMsg struct {
+	Path string
+	Err  error
+}
+
+// formatMediaPlaceholder describes an attachment, e.g. "[image/jpeg 1.2 MB photo.jpg]"
+func formatMediaPlaceholder(msg *store.Message) string {
+	parts := make([]string, 0, 3)
+
+	mimeType := msg.MediaType
+	if mimeType == "" {
+		mimeType = "attachment"
+	}
+	parts = append(parts, mimeType)
+
+	if msg.MediaSize > 0 {
+		parts = append(parts, formatSize(msg.MediaSize))
+	}
+	if msg.MediaName != "" {
+		parts = append(parts, msg.MediaName)
+	}
+
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// formatSize formats a byte count in human readable units
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// OpenFileCmd opens a file with the platform's default viewer without
+// suspending the TUI
+func OpenFileCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		opener := "xdg-open"
+		switch runtime.GOOS {
+		case "darwin":
+			opener = "open"
+		case "windows":
+			opener = "explorer"
+		}
+
+		if err := exec.Command(opener, path).Start(); err != nil {
+			return MediaOpenedMsg{Path: path, Err: fmt.Errorf("failed to open %s: %w", path, err)}
+		}
+		return MediaOpenedMsg{Path: path}
+	}
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,6 +21,12 @@ type MessagesKeyMap struct {
 	Top      key.Binding
 	Bottom   key.Binding
 	React    key.Binding
+	Open     key.Binding
+	Reply    key.Binding
+	Retry    key.Binding
+	Discard  key.Binding
+	Edit     key.Binding
+	Members  key.Binding
 }
 
 // DefaultMessagesKeyMap returns the default key bindings
@@ -53,6 +60,30 @@ func DefaultMessagesKeyMap() MessagesKeyMap {
 			key.WithKeys("ctrl+r"),
 			key.WithHelp("ctrl+r", "react"),
 		),
+		Open: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "open attachment"),
+		),
+		Reply: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "reply"),
+		),
+		Retry: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "retry failed message"),
+		),
+		Discard: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "discard failed or scheduled message"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "edit scheduled message"),
+		),
+		Members: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "members"),
+		),
 	}
 }
 
@@ -60,6 +91,7 @@ func DefaultMessagesKeyMap() MessagesKeyMap {
 type MessagesModel struct {
 	messages       []*store.Message
 	conversationID string
+	conversation   *store.Conversation // for naming senders, may be nil
 	selected       int
 	offset         int
 	width          int
@@ -67,7 +99,23 @@ type MessagesModel struct {
 	focused        bool
 	styles         *Styles
 	keyMap         MessagesKeyMap
-	lastKeyWasG    bool // Track if last key was 'g' for gg combo
+	lastKeyWasG    bool   // Track if last key was 'g' for gg combo
+	lastKeyWasD    bool   // Track if last key was 'd' for dd combo
+	hasOlder       bool   // Older messages can be fetched
+	loadingOlder   bool   // An older page is being fetched
+	picking        bool   // The reaction chooser is open
+	pickIndex      int    // Highlighted emoji in the reaction chooser
+	typing         string // Who is typing in this conversation, if anyone
+}
+
+// LoadOlderMessagesMsg is sent when the selection reaches the oldest loaded message
+type LoadOlderMessagesMsg struct {
+	ConversationID string
+}
+
+// ReachedNewestMsg is sent when the selection reaches the newest message
+type ReachedNewestMsg struct {
+	ConversationID string
 }
 
 // NewMessagesModel creates a new messages panel model
@@ -92,6 +140,10 @@ func (m MessagesModel) Update(msg tea.Msg) (MessagesModel, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.picking {
+			return m.handlePickerKey(msg)
+		}
+
 		// Handle gg combo for going to top
 		if msg.String() == "g" {
 			if m.lastKeyWasG {
@@ -99,13 +151,29 @@ func (m MessagesModel) Update(msg tea.Msg) (MessagesModel, tea.Cmd) {
 				m.selected = 0
 				m.offset = 0
 				m.lastKeyWasG = false
-				return m, nil
+				return m, m.loadOlderIfNeeded()
 			}
 			m.lastKeyWasG = true
 			return m, nil
 		}
 		m.lastKeyWasG = false
 
+		// Handle dd combo for deleting the selected message
+		if msg.String() == "d" {
+			if m.lastKeyWasD {
+				m.lastKeyWasD = false
+				if sel := m.SelectedMessage(); sel != nil {
+					return m, func() tea.Msg {
+						return DeleteMessageMsg{Message: sel}
+					}
+				}
+				return m, nil
+			}
+			m.lastKeyWasD = true
+			return m, nil
+		}
+		m.lastKeyWasD = false
+
 		switch {
 		case key.Matches(msg, m.keyMap.Up):
 			if m.selected > 0 {
@@ -114,6 +182,7 @@ func (m MessagesModel) Update(msg tea.Msg) (MessagesModel, tea.Cmd) {
 					m.offset = m.selected
 				}
 			}
+			return m, m.loadOlderIfNeeded()
 
 		case key.Matches(msg, m.keyMap.Down):
 			if m.selected < len(m.messages)-1 {
@@ -122,23 +191,27 @@ func (m MessagesModel) Update(msg tea.Msg) (MessagesModel, tea.Cmd) {
 				if m.selected >= m.offset+visibleItems {
 					m.offset = m.selected - visibleItems + 1
 				}
+				return m, m.reachedNewest()
 			}
 
 		case key.Matches(msg, m.keyMap.PageUp):
 			pageSize := m.visibleItemCount()
 			m.selected = max(0, m.selected-pageSize)
 			m.offset = max(0, m.offset-pageSize)
+			return m, m.loadOlderIfNeeded()
 
 		case key.Matches(msg, m.keyMap.PageDown):
 			pageSize := m.visibleItemCount()
 			maxSelect := len(m.messages) - 1
 			m.selected = min(maxSelect, m.selected+pageSize)
 			m.offset = min(max(0, len(m.messages)-pageSize), m.offset+pageSize)
+			return m, m.reachedNewest()
 
 		case key.Matches(msg, m.keyMap.Top):
 			// Home - go to top
 			m.selected = 0
 			m.offset = 0
+			return m, m.loadOlderIfNeeded()
 
 		case key.Matches(msg, m.keyMap.Bottom):
 			// G/End - go to bottom
@@ -146,12 +219,87 @@ func (m MessagesModel) Update(msg tea.Msg) (MessagesModel, tea.Cmd) {
 				m.selected = len(m.messages) - 1
 				m.offset = max(0, len(m.messages)-m.visibleItemCount())
 			}
+			return m, m.reachedNewest()
+
+		case key.Matches(msg, m.keyMap.React):
+			m.openReactionPicker()
+
+		case key.Matches(msg, m.keyMap.Open):
+			if sel := m.SelectedMessage(); sel != nil && sel.HasMedia() {
+				return m, func() tea.Msg {
+					return OpenMediaMsg{Message: sel}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.Reply):
+			// Only messages the phone knows about can be quoted
+			if sel := m.SelectedMessage(); sel != nil && !sel.IsLocal() {
+				return m, func() tea.Msg {
+					return ReplyMsg{Message: sel}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.Retry):
+			if sel := m.SelectedMessage(); sel != nil && sel.IsLocal() && sel.Status == store.StatusFailed {
+				return m, func() tea.Msg {
+					return RetryMessageMsg{Message: sel}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.Members):
+			if m.conversationID != "" {
+				id := m.conversationID
+				return m, func() tea.Msg {
+					return ShowRosterMsg{ConversationID: id}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.Discard):
+			if sel := m.SelectedMessage(); sel != nil && sel.IsLocal() && (sel.Status == store.StatusFailed || sel.Status == store.StatusQueued || sel.Status == store.StatusScheduled) {
+				return m, func() tea.Msg {
+					return DiscardMessageMsg{Message: sel}
+				}
+			}
+
+		case key.Matches(msg, m.keyMap.Edit):
+			if sel := m.SelectedMessage(); sel != nil && sel.IsLocal() && sel.Status == store.StatusScheduled {
+				return m, func() tea.Msg {
+					return EditScheduledMsg{Message: sel}
+				}
+			}
 		}
 	}
 
 	return m, nil
 }
 
+// loadOlderIfNeeded requests the next older page when the top is selected
+func (m MessagesModel) loadOlderIfNeeded() tea.Cmd {
+	if m.selected != 0 || !m.hasOlder || m.loadingOlder || m.conversationID == "" {
+		return nil
+	}
+	convID := m.conversationID
+	return func() tea.Msg {
+		return LoadOlderMessagesMsg{ConversationID: convID}
+	}
+}
+
+// reachedNewest reports when the newest message is selected
+func (m MessagesModel) reachedNewest() tea.Cmd {
+	if !m.AtNewest() {
+		return nil
+	}
+	convID := m.conversationID
+	return func() tea.Msg {
+		return ReachedNewestMsg{ConversationID: convID}
+	}
+}
+
+// AtNewest returns whether the newest message of the conversation is selected
+func (m MessagesModel) AtNewest() bool {
+	return m.conversationID != "" && len(m.messages) > 0 && m.selected == len(m.messages)-1
+}
+
 // View renders the messages panel
 func (m MessagesModel) View() string {
 	var b strings.Builder
@@ -162,6 +310,9 @@ func (m MessagesModel) View() string {
 		title = fmt.Sprintf("Messages (%d)", len(m.messages))
 	}
 	b.WriteString(m.styles.PanelTitleText.Render(title))
+	if m.typing != "" {
+		b.WriteString(" " + m.styles.Typing.Render(m.typing))
+	}
 	b.WriteString("\n")
 
 	if len(m.messages) == 0 {
@@ -175,12 +326,28 @@ func (m MessagesModel) View() string {
 	} else {
 		// Calculate available height
 		availableHeight := m.height - 3
+		if m.picking {
+			availableHeight--
+		}
+
+		// History marker above the oldest loaded message
+		if m.offset == 0 {
+			if m.loadingOlder {
+				b.WriteString(m.styles.MessageTime.Render("loading older…"))
+				b.WriteString("\n")
+				availableHeight--
+			} else if !m.hasOlder {
+				b.WriteString(m.styles.MessageTime.Render("— beginning of conversation —"))
+				b.WriteString("\n")
+				availableHeight--
+			}
+		}
 
 		// Render messages
 		visibleCount := 0
 		for i := m.offset; i < len(m.messages) && visibleCount < availableHeight; i++ {
 			msg := m.messages[i]
-			rendered := m.renderMessage(msg, i == m.selected)
+			rendered := m.renderMessage(msg, i == m.selected, m.startsRun(i))
 			lines := strings.Count(rendered, "\n") + 1
 			if visibleCount+lines > availableHeight {
 				break
@@ -189,6 +356,10 @@ func (m MessagesModel) View() string {
 			b.WriteString("\n")
 			visibleCount += lines
 		}
+
+		if m.picking {
+			b.WriteString(m.pickerView())
+		}
 	}
 
 	// Apply panel style
@@ -200,8 +371,9 @@ func (m MessagesModel) View() string {
 	return style.Width(m.width).Height(m.height).Render(b.String())
 }
 
-// renderMessage renders a single message
-func (m MessagesModel) renderMessage(msg *store.Message, selected bool) string {
+// renderMessage renders a single message, under its sender's name if
+// showSender is set
+func (m MessagesModel) renderMessage(msg *store.Message, selected, showSender bool) string {
 	maxWidth := m.width - 6 // Account for padding and borders
 
 	// Determine message style based on sender
@@ -227,46 +399,98 @@ func (m MessagesModel) renderMessage(msg *store.Message, selected bool) string {
 	// Wrap content to max width
 	content = wrapText(content, maxWidth-4)
 
-	// Format time
+	// Attachment placeholder above any caption
+	if msg.HasMedia() {
+		placeholder := m.styles.MessageMedia.Render(formatMediaPlaceholder(msg))
+		if content == "" {
+			content = placeholder
+		} else {
+			content = placeholder + "\n" + content
+		}
+	}
+
+	// Format time, or when a scheduled message goes out
 	timeStr := msg.Timestamp.Format("15:04")
+	if msg.Status == store.StatusScheduled {
+		timeStr = formatSendTime(msg.Timestamp, time.Now())
+	}
 
 	// Format status for sent messages
 	statusStr := ""
 	if msg.IsFromMe {
 		switch msg.Status {
+		case store.StatusPending:
+			statusStr = " …"
+		case store.StatusQueued:
+			statusStr = " ⏳ waiting for phone"
 		case "delivered":
 			statusStr = " ✓"
 		case "read":
 			statusStr = " ✓✓"
-		case "failed":
-			statusStr = " ✗"
+		case store.StatusFailed:
+			statusStr = " ✗ not sent"
+		case store.StatusScheduled:
+			statusStr = " ⏰ scheduled"
+			if !msg.Timestamp.After(time.Now()) {
+				statusStr = " ⏰ overdue"
+			}
 		}
 	}
 
 	// Build the message
 	var result strings.Builder
 
-	// Sender name for received messages in groups
-	if !msg.IsFromMe && msg.SenderName != "" {
-		result.WriteString(m.styles.MessageSender.Render(msg.SenderName))
+	// Quoted header for replies
+	if msg.ReplyToID != "" {
+		result.WriteString(m.quoteView(msg, maxWidth-4))
 		result.WriteString("\n")
 	}
 
+	// Sender name above each run of received messages
+	if showSender && !msg.IsFromMe {
+		if name := m.senderName(msg); name != "" {
+			result.WriteString(m.senderStyle(msg).Render(name))
+			result.WriteString("\n")
+		}
+	}
+
 	// Message content
 	result.WriteString(content)
 
 	// Time and status on the same line
 	footer := m.styles.MessageTime.Render(timeStr)
 	if statusStr != "" {
-		if msg.Status == "read" {
+		switch msg.Status {
+		case "read":
 			footer += m.styles.MessageStatusRead.Render(statusStr)
-		} else {
+		case store.StatusFailed:
+			footer += m.styles.MessageStatusFailed.Render(statusStr)
+			if selected && msg.IsLocal() {
+				footer += m.styles.MessageStatus.Render(" · R retry · X discard")
+			}
+		case store.StatusQueued:
+			footer += m.styles.MessageStatus.Render(statusStr)
+			if selected && msg.IsLocal() {
+				footer += m.styles.MessageStatus.Render(" · X discard")
+			}
+		case store.StatusScheduled:
+			footer += m.styles.MessageStatus.Render(statusStr)
+			if selected && msg.IsLocal() {
+				footer += m.styles.MessageStatus.Render(" · e edit · X cancel")
+			}
+		default:
 			footer += m.styles.MessageStatus.Render(statusStr)
 		}
 	}
 	result.WriteString("\n")
 	result.WriteString(footer)
 
+	// Reactions under the bubble
+	if len(msg.Reactions) > 0 {
+		result.WriteString("\n")
+		result.WriteString(m.formatReactions(msg.Reactions, maxWidth-4))
+	}
+
 	// Apply alignment
 	renderedMsg := msgStyle.MaxWidth(maxWidth).Render(result.String())
 
@@ -288,8 +512,30 @@ func (m MessagesModel) visibleItemCount() int {
 
 // SetMessages updates the message list
 func (m *MessagesModel) SetMessages(conversationID string, msgs []*store.Message) {
+	sameConversation := conversationID == m.conversationID
+	var selectedID string
+	if sel := m.SelectedMessage(); sel != nil && m.selected < len(m.messages)-1 {
+		selectedID = sel.ID
+	}
+
 	m.conversationID = conversationID
 	m.messages = msgs
+	if !sameConversation {
+		m.hasOlder = false
+		m.loadingOlder = false
+		m.picking = false
+	}
+
+	// Keep the selection when reloading a conversation scrolled back in history
+	if sameConversation && selectedID != "" {
+		for i, msg := range msgs {
+			if msg.ID == selectedID {
+				m.offset = max(0, m.offset+i-m.selected)
+				m.selected = i
+				return
+			}
+		}
+	}
 
 	// Scroll to bottom on new conversation
 	if len(msgs) > 0 {
@@ -301,17 +547,91 @@ func (m *MessagesModel) SetMessages(conversationID string, msgs []*store.Message
 	}
 }
 
+// PrependMessages adds older messages above the loaded history while keeping
+// the same message selected and on screen
+func (m *MessagesModel) PrependMessages(conversationID string, older []*store.Message) {
+	if conversationID != m.conversationID {
+		return
+	}
+
+	known := make(map[string]bool, len(m.messages))
+	for _, msg := range m.messages {
+		known[msg.ID] = true
+	}
+	fresh := make([]*store.Message, 0, len(older))
+	for _, msg := range older {
+		if !known[msg.ID] {
+			fresh = append(fresh, msg)
+		}
+	}
+
+	m.messages = append(fresh, m.messages...)
+	m.selected += len(fresh)
+	m.offset += len(fresh)
+}
+
+// SetTyping sets who is shown typing in the title, or nobody if empty
+func (m *MessagesModel) SetTyping(label string) {
+	m.typing = label
+}
+
+// SetHasOlder records whether older messages can be fetched
+func (m *MessagesModel) SetHasOlder(hasOlder bool) {
+	m.hasOlder = hasOlder
+}
+
+// SetLoadingOlder shows or hides the "loading older" indicator
+func (m *MessagesModel) SetLoadingOlder(loading bool) {
+	m.loadingOlder = loading
+}
+
+// LoadingOlder returns whether an older page is being fetched
+func (m MessagesModel) LoadingOlder() bool {
+	return m.loadingOlder
+}
+
 // AddMessage adds a new message and scrolls to it
 func (m *MessagesModel) AddMessage(msg *store.Message) {
 	if msg.ConversationID != m.conversationID {
 		return
 	}
+	if m.UpdateMessage(msg) {
+		return
+	}
 	m.messages = append(m.messages, msg)
 	// Auto-scroll to new message
 	m.selected = len(m.messages) - 1
 	m.offset = max(0, len(m.messages)-m.visibleItemCount())
 }
 
+// UpdateMessage replaces a shown message with a newer version of itself, or
+// the local echo it was sent as, keeping the scroll position. Returns false
+// if the message isn't shown.
+func (m *MessagesModel) UpdateMessage(msg *store.Message) bool {
+	if msg.ConversationID != m.conversationID {
+		return false
+	}
+	for i, existing := range m.messages {
+		if existing.ID == msg.ID || (msg.TmpID != "" && existing.ID == msg.TmpID) {
+			m.messages[i] = msg
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveMessage drops a message from the panel
+func (m *MessagesModel) RemoveMessage(id string) {
+	for i, existing := range m.messages {
+		if existing.ID == id {
+			m.messages = append(m.messages[:i:i], m.messages[i+1:]...)
+			break
+		}
+	}
+	m.selected = min(m.selected, max(0, len(m.messages)-1))
+	m.offset = min(m.offset, m.selected)
+}
+
 // SetSize sets the panel dimensions
 func (m *MessagesModel) SetSize(width, height int) {
 	m.width = width
@@ -321,6 +641,9 @@ func (m *MessagesModel) SetSize(width, height int) {
 // SetFocused sets the focus state
 func (m *MessagesModel) SetFocused(focused bool) {
 	m.focused = focused
+	if !focused {
+		m.picking = false
+	}
 }
 
 // SelectedMessage returns the currently selected message
@@ -337,6 +660,7 @@ func (m *MessagesModel) Clear() {
 	m.conversationID = ""
 	m.selected = 0
 	m.offset = 0
+	m.picking = false
 }
 
 // wrapText wraps text to the specified width
@@ -0,0 +1,328 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/n0ko/messages-tui/internal/client"
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// newChatMatches is how many matching contacts the dialog lists
+const newChatMatches = 6
+
+// NewConversationMsg is sent when the user wants to start a conversation
+type NewConversationMsg struct{}
+
+// CreateConversationMsg is sent when the user confirms the recipients of a
+// new conversation
+type CreateConversationMsg struct {
+	Numbers []string
+}
+
+// contactsFetchedMsg reports that the phone's contact list was synced into
+// the store
+type contactsFetchedMsg struct {
+	err error
+}
+
+// conversationCreatedMsg reports the conversation opened for new recipients
+type conversationCreatedMsg struct {
+	conversation *store.Conversation
+	err          error
+}
+
+// recipient is someone added to a new conversation
+type recipient struct {
+	name   string
+	number string
+}
+
+// NewChatModel is the dialog for picking the recipients of a new conversation
+type NewChatModel struct {
+	textInput  textinput.Model
+	styles     *Styles
+	contacts   []*store.Contact
+	matches    []*store.Contact
+	selected   int
+	recipients []recipient
+	loading    bool   // Contacts are being fetched
+	creating   bool   // The conversation is being created
+	hint       string // Validation or error feedback
+	account    string // Account the conversation is created on, when there are several
+}
+
+// NewNewChatModel creates the new conversation dialog, waiting for contacts
+func NewNewChatModel(styles *Styles) NewChatModel {
+	ti := textinput.New()
+	ti.Placeholder = "Name or phone number"
+	ti.CharLimit = 100
+	ti.Width = 40
+	ti.Focus()
+
+	return NewChatModel{
+		textInput: ti,
+		styles:    styles,
+		loading:   true,
+	}
+}
+
+// SetContacts sets the contacts to pick from
+func (m *NewChatModel) SetContacts(contacts []*store.Contact) {
+	m.contacts = contacts
+	m.loading = false
+	m.filter()
+}
+
+// SetError shows why fetching contacts or creating the conversation failed
+func (m *NewChatModel) SetError(err error) {
+	m.loading = false
+	m.creating = false
+	m.hint = err.Error()
+}
+
+// Update handles keys for the dialog. closed is true once the user cancels.
+func (m NewChatModel) Update(msg tea.KeyMsg) (model NewChatModel, cmd tea.Cmd, closed bool) {
+	if m.creating {
+		return m, nil, false
+	}
+
+	switch msg.Type {
+	case tea.KeyEscape:
+		return m, nil, true
+
+	case tea.KeyUp, tea.KeyCtrlP:
+		if m.selected > 0 {
+			m.selected--
+		}
+		return m, nil, false
+
+	case tea.KeyDown, tea.KeyCtrlN:
+		if m.selected < len(m.matches)-1 {
+			m.selected++
+		}
+		return m, nil, false
+
+	case tea.KeyTab:
+		m.addSelected()
+		return m, nil, false
+
+	case tea.KeyBackspace:
+		if m.textInput.Value() == "" && len(m.recipients) > 0 {
+			m.recipients = m.recipients[:len(m.recipients)-1]
+			return m, nil, false
+		}
+
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.textInput.Value())
+		if value != "" {
+			if numbers, ok := parseNumbers(value); ok {
+				for _, number := range numbers {
+					m.addRecipient(recipient{number: number})
+				}
+				m.textInput.Reset()
+				m.filter()
+			} else if !m.addSelected() {
+				m.hint = "not a phone number or contact"
+			}
+			return m, nil, false
+		}
+		if len(m.recipients) == 0 {
+			m.hint = "add a recipient first"
+			return m, nil, false
+		}
+
+		numbers := make([]string, len(m.recipients))
+		for i, r := range m.recipients {
+			numbers[i] = r.number
+		}
+		m.creating = true
+		m.hint = ""
+		return m, func() tea.Msg {
+			return CreateConversationMsg{Numbers: numbers}
+		}, false
+	}
+
+	before := m.textInput.Value()
+	m.textInput, cmd = m.textInput.Update(msg)
+	if m.textInput.Value() != before {
+		m.hint = ""
+		m.filter()
+	}
+	return m, cmd, false
+}
+
+// addSelected adds the highlighted contact as a recipient
+func (m *NewChatModel) addSelected() bool {
+	if m.selected >= len(m.matches) {
+		return false
+	}
+	contact := m.matches[m.selected]
+	m.addRecipient(recipient{name: contact.Name, number: contact.Number})
+	m.textInput.Reset()
+	m.filter()
+	return true
+}
+
+// addRecipient adds a recipient unless they were added already
+func (m *NewChatModel) addRecipient(r recipient) {
+	for _, existing := range m.recipients {
+		if normalizeNumber(existing.number) == normalizeNumber(r.number) {
+			return
+		}
+	}
+	m.recipients = append(m.recipients, r)
+}
+
+// filter lists the contacts whose name or number matches the typed text
+func (m *NewChatModel) filter() {
+	query := strings.ToLower(strings.TrimSpace(m.textInput.Value()))
+	digits := normalizeNumber(query)
+
+	m.matches = nil
+	m.selected = 0
+	if query == "" {
+		return
+	}
+	for _, contact := range m.contacts {
+		if strings.Contains(strings.ToLower(contact.Name), query) ||
+			(digits != "" && strings.Contains(normalizeNumber(contact.Number), digits)) {
+			m.matches = append(m.matches, contact)
+			if len(m.matches) == newChatMatches {
+				break
+			}
+		}
+	}
+}
+
+// View renders the dialog
+func (m NewChatModel) View() string {
+	title := "New conversation"
+	if m.account != "" {
+		title += " on @" + m.account
+	}
+	lines := []string{m.styles.DialogTitle.Render(title)}
+
+	to := "To: "
+	if len(m.recipients) == 0 {
+		to += m.styles.MessageStatus.Render("nobody yet")
+	}
+	for i, r := range m.recipients {
+		if i > 0 {
+			to += ", "
+		}
+		if r.name != "" {
+			to += r.name + m.styles.MessageStatus.Render(" "+r.number)
+		} else {
+			to += r.number
+		}
+	}
+	if len(m.recipients) > 1 {
+		to += m.styles.MessageStatus.Render(" (group)")
+	}
+	lines = append(lines, to, "", m.textInput.View())
+
+	switch {
+	case m.loading:
+		lines = append(lines, m.styles.MessageStatus.Render("Loading contacts..."))
+	case m.creating:
+		lines = append(lines, m.styles.MessageStatus.Render("Opening conversation..."))
+	}
+	for i, contact := range m.matches {
+		line := fmt.Sprintf("%s  %s", contact.Name, m.styles.MessageStatus.Render(contact.FormattedNumber))
+		if i == m.selected {
+			line = m.styles.MessageSender.Render("→ ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	if m.hint != "" {
+		lines = append(lines, m.styles.MessageStatusFailed.Render(m.hint))
+	}
+
+	lines = append(lines, "", m.styles.MessageStatus.Render("Enter: add / start · Tab: add contact · ↑/↓: choose · Esc: cancel"))
+	return m.styles.Dialog.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// parseNumbers splits input into phone numbers separated by commas or
+// semicolons. ok is false unless every part looks like a phone number.
+func parseNumbers(input string) (numbers []string, ok bool) {
+	for _, part := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ';' }) {
+		part = strings.TrimSpace(part)
+		if len(normalizeNumber(part)) < 3 || strings.TrimLeft(part, "+0123456789 -().") != "" {
+			return nil, false
+		}
+		numbers = append(numbers, strings.Map(func(r rune) rune {
+			if r == '+' || (r >= '0' && r <= '9') {
+				return r
+			}
+			return -1
+		}, part))
+	}
+	return numbers, len(numbers) > 0
+}
+
+// normalizeNumber keeps only the digits of a phone number, for comparing
+func normalizeNumber(number string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+}
+
+// fetchContacts syncs the phone's contacts, for naming conversations and
+// for the new conversation dialog
+func (a *App) fetchContacts() tea.Cmd {
+	return func() tea.Msg {
+		_, err := a.client.ListContacts(a.ctx)
+		if err != nil {
+			log.Printf("App: ListContacts error: %v", err)
+		}
+		return contactsFetchedMsg{err: err}
+	}
+}
+
+// refreshNames shows the names from a new contact list in the panels
+func (a *App) refreshNames() {
+	a.contacts.SetConversations(a.store.GetConversations())
+	if a.activeConversationID == "" {
+		return
+	}
+	a.messages.SetConversation(a.store.GetConversation(a.activeConversationID))
+	for _, msg := range a.store.GetMessages(a.activeConversationID) {
+		a.messages.UpdateMessage(msg)
+	}
+}
+
+// createConversation opens the conversation with the given numbers, on the
+// account the dialog was opened in
+func (a *App) createConversation(numbers []string) tea.Cmd {
+	ctx := a.ctx
+	if a.newChat != nil && a.newChat.account != "" {
+		ctx = client.WithAccount(ctx, a.newChat.account)
+	}
+	return func() tea.Msg {
+		conv, err := a.client.CreateConversation(ctx, numbers)
+		if err != nil {
+			log.Printf("App: CreateConversation error: %v", err)
+		}
+		return conversationCreatedMsg{conversation: conv, err: err}
+	}
+}
+
+// renderNewChat renders the new conversation dialog
+func (a *App) renderNewChat() string {
+	return lipgloss.Place(
+		a.width, a.height,
+		lipgloss.Center, lipgloss.Center,
+		a.newChat.View(),
+	)
+}
@@ -0,0 +1,356 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// muteCheckInterval is how often mutes are checked for expiry
+const muteCheckInterval = time.Minute
+
+// muteChoices are the durations offered when muting, zero meaning until
+// unmuted
+var muteChoices = []struct {
+	label    string
+	duration time.Duration
+}{
+	{"1 hour", time.Hour},
+	{"8 hours", 8 * time.Hour},
+	{"1 week", 7 * 24 * time.Hour},
+	{"Until I unmute", 0},
+}
+
+// conversationArchivedMsg reports the result of archiving a conversation
+type conversationArchivedMsg struct {
+	conversationID string
+	archived       bool
+	err            error
+}
+
+// conversationMutedMsg reports whether the phone took a mute change
+type conversationMutedMsg struct {
+	conversationID string
+	muted          bool
+	err            error
+}
+
+// conversationBlockedMsg reports the result of blocking or unblocking a
+// conversation
+type conversationBlockedMsg struct {
+	conversationID string
+	blocked        bool
+	report         bool
+	err            error
+}
+
+// blockedLoadedMsg reports the result of fetching the spam & blocked folder
+type blockedLoadedMsg struct {
+	err error
+}
+
+// muteTickMsg triggers a check for expired mutes
+type muteTickMsg struct{}
+
+// toggleArchive archives or unarchives a conversation on the phone
+func (a *App) toggleArchive(conversationID string) tea.Cmd {
+	conv := a.store.GetConversation(conversationID)
+	if conv == nil {
+		return nil
+	}
+	archived := !conv.Archived
+	if archived {
+		a.statusMsg = "Archiving..."
+	} else {
+		a.statusMsg = "Unarchiving..."
+	}
+	return func() tea.Msg {
+		err := a.client.ArchiveConversation(a.ctx, conversationID, archived)
+		if err != nil {
+			log.Printf("App: ArchiveConversation error: %v", err)
+		}
+		return conversationArchivedMsg{conversationID: conversationID, archived: archived, err: err}
+	}
+}
+
+// handleArchived shows the result of archiving a conversation
+func (a *App) handleArchived(msg conversationArchivedMsg) {
+	if msg.err != nil {
+		a.statusMsg = fmt.Sprintf("Error: %v", msg.err)
+		return
+	}
+	a.contacts.SetConversations(a.store.GetConversations())
+	if msg.archived {
+		a.statusMsg = "Archived (A: show archived)"
+	} else {
+		a.statusMsg = "Moved back to the inbox"
+	}
+}
+
+// togglePin pins or unpins a conversation on this device
+func (a *App) togglePin(conversationID string) {
+	conv := a.store.GetConversation(conversationID)
+	if conv == nil {
+		return
+	}
+	pinned := !conv.Pinned
+	if err := a.store.SetPinned(conversationID, pinned); err != nil {
+		// The pin still holds until the app exits
+		log.Printf("App: failed to save conversation settings: %v", err)
+	}
+	a.contacts.SetConversations(a.store.GetConversations())
+	if pinned {
+		a.statusMsg = "Pinned"
+	} else {
+		a.statusMsg = "Unpinned"
+	}
+}
+
+// toggleMute unmutes a muted conversation, or asks how long to mute it for
+func (a *App) toggleMute(conversationID string) tea.Cmd {
+	conv := a.store.GetConversation(conversationID)
+	if conv == nil {
+		return nil
+	}
+	if !conv.IsMuted(time.Now()) {
+		a.muting = conv
+		return nil
+	}
+	return a.setMuted(conversationID, false, time.Time{})
+}
+
+// handleMuteChoiceKey answers the mute duration chooser
+func (a *App) handleMuteChoiceKey(msg tea.KeyMsg) tea.Cmd {
+	switch s := msg.String(); s {
+	case "1", "2", "3", "4":
+		choice := muteChoices[s[0]-'1']
+		convID := a.muting.ID
+		a.muting = nil
+		var until time.Time
+		if choice.duration > 0 {
+			until = time.Now().Add(choice.duration)
+		}
+		return a.setMuted(convID, true, until)
+
+	case "esc", "q", "n":
+		a.muting = nil
+	}
+	return nil
+}
+
+// setMuted mutes or unmutes a conversation here and on the phone. The mute
+// applies here even if the phone can't be told.
+func (a *App) setMuted(conversationID string, muted bool, until time.Time) tea.Cmd {
+	if err := a.store.SetMuted(conversationID, muted, until); err != nil {
+		log.Printf("App: failed to save conversation settings: %v", err)
+	}
+	a.contacts.SetConversations(a.store.GetConversations())
+
+	switch {
+	case !muted:
+		a.statusMsg = "Unmuted"
+	case until.IsZero():
+		a.statusMsg = "Muted"
+	default:
+		a.statusMsg = "Muted until " + until.Format("Mon 15:04")
+	}
+	return a.muteOnPhone(conversationID, muted)
+}
+
+// muteOnPhone tells the phone about a mute change
+func (a *App) muteOnPhone(conversationID string, muted bool) tea.Cmd {
+	return func() tea.Msg {
+		err := a.client.MuteConversation(a.ctx, conversationID, muted)
+		if err != nil {
+			log.Printf("App: MuteConversation error: %v", err)
+		}
+		return conversationMutedMsg{conversationID: conversationID, muted: muted, err: err}
+	}
+}
+
+// scheduleMuteCheck waits for the next expired mute check
+func scheduleMuteCheck() tea.Cmd {
+	return tea.Tick(muteCheckInterval, func(time.Time) tea.Msg {
+		return muteTickMsg{}
+	})
+}
+
+// expireMutes unmutes the conversations whose mute ran out, on the phone too
+func (a *App) expireMutes() tea.Cmd {
+	expired, err := a.store.ExpireMutes(time.Now())
+	if err != nil {
+		log.Printf("App: failed to save conversation settings: %v", err)
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	a.contacts.SetConversations(a.store.GetConversations())
+	cmds := make([]tea.Cmd, len(expired))
+	for i, id := range expired {
+		cmds[i] = a.muteOnPhone(id, false)
+	}
+	return tea.Batch(cmds...)
+}
+
+// toggleBlock unblocks a blocked conversation, or asks whether to block it
+// and report it as spam
+func (a *App) toggleBlock(conversationID string) tea.Cmd {
+	conv := a.store.GetConversation(conversationID)
+	if conv == nil {
+		return nil
+	}
+	if !conv.Blocked {
+		a.blocking = conv
+		return nil
+	}
+
+	a.statusMsg = "Unblocking..."
+	return func() tea.Msg {
+		err := a.client.UnblockConversation(a.ctx, conversationID)
+		if err != nil {
+			log.Printf("App: UnblockConversation error: %v", err)
+		}
+		return conversationBlockedMsg{conversationID: conversationID, err: err}
+	}
+}
+
+// handleBlockConfirmKey answers the block dialog
+func (a *App) handleBlockConfirmKey(msg tea.KeyMsg) tea.Cmd {
+	var report bool
+	switch msg.String() {
+	case "r":
+		report = true
+	case "b", "y":
+	case "esc", "q", "n":
+		a.blocking = nil
+		return nil
+	default:
+		return nil
+	}
+
+	convID := a.blocking.ID
+	a.blocking = nil
+	a.statusMsg = "Blocking..."
+	return func() tea.Msg {
+		err := a.client.BlockConversation(a.ctx, convID, report)
+		if err != nil {
+			log.Printf("App: BlockConversation error: %v", err)
+		}
+		return conversationBlockedMsg{conversationID: convID, blocked: true, report: report, err: err}
+	}
+}
+
+// handleBlocked shows the result of blocking or unblocking a conversation
+func (a *App) handleBlocked(msg conversationBlockedMsg) {
+	if msg.err != nil {
+		a.statusMsg = fmt.Sprintf("Error: %v", msg.err)
+		return
+	}
+	a.contacts.SetConversations(a.store.GetConversations())
+	switch {
+	case msg.report:
+		a.statusMsg = "Blocked and reported as spam (S: spam & blocked)"
+	case msg.blocked:
+		a.statusMsg = "Blocked (S: spam & blocked)"
+	default:
+		a.statusMsg = "Unblocked and moved back to the inbox"
+	}
+}
+
+// loadBlocked fetches the spam & blocked folder, which the conversation list
+// leaves out until it is opened
+func (a *App) loadBlocked() tea.Cmd {
+	return func() tea.Msg {
+		err := a.client.LoadBlockedConversations(a.ctx)
+		if err != nil {
+			log.Printf("App: LoadBlockedConversations error: %v", err)
+		}
+		return blockedLoadedMsg{err: err}
+	}
+}
+
+// handleBlockedLoaded lists the fetched spam & blocked folder
+func (a *App) handleBlockedLoaded(msg blockedLoadedMsg) {
+	if msg.err != nil {
+		a.statusMsg = fmt.Sprintf("Error: %v", msg.err)
+		return
+	}
+	a.contacts.SetConversations(a.store.GetConversations())
+}
+
+// notify tells the user about a new incoming message in a conversation that
+// isn't open, unless the conversation is muted or blocked
+func (a *App) notify(msg *store.Message) tea.Cmd {
+	if msg.IsFromMe || msg.ConversationID == a.activeConversationID {
+		return nil
+	}
+	conv := a.store.GetConversation(msg.ConversationID)
+	if conv == nil || conv.Blocked || conv.IsMuted(time.Now()) {
+		return nil
+	}
+
+	from := conv.Name
+	if conv.IsGroup && msg.SenderName != "" {
+		from = fmt.Sprintf("%s in %s", msg.SenderName, conv.Name)
+	}
+	a.statusMsg = "New message from " + from
+	if !a.cfg.Notifications.Bell {
+		return nil
+	}
+	return func() tea.Msg {
+		// Stderr shares the terminal without disturbing the renderer
+		fmt.Fprint(os.Stderr, "\a")
+		return nil
+	}
+}
+
+// renderMuteChooser renders the mute duration chooser
+func (a *App) renderMuteChooser() string {
+	lines := []string{
+		a.styles.DialogTitle.Render("Mute " + a.muting.Name),
+		"",
+	}
+	for i, choice := range muteChoices {
+		lines = append(lines, fmt.Sprintf("%s  %s", a.styles.DialogButton.Render(fmt.Sprint(i+1)), choice.label))
+	}
+	lines = append(lines, "", a.styles.MessageStatus.Render("No notifications while muted · Esc: cancel"))
+
+	box := a.styles.Dialog.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+
+	return lipgloss.Place(
+		a.width, a.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
+
+// renderBlockConfirm renders the dialog asking whether to block a
+// conversation
+func (a *App) renderBlockConfirm() string {
+	lines := []string{
+		a.styles.DialogTitle.Render("Block " + a.blocking.Name + "?"),
+		"",
+		"You won't get their messages or calls. The conversation",
+		"moves to Spam & blocked, where you can unblock it.",
+		"",
+		fmt.Sprintf("%s  Block and report spam", a.styles.DialogButton.Render("r")),
+		fmt.Sprintf("%s  Block only", a.styles.DialogButton.Render("b")),
+		"",
+		a.styles.MessageStatus.Render("Esc: cancel"),
+	}
+
+	box := a.styles.Dialog.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+
+	return lipgloss.Place(
+		a.width, a.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
@@ -0,0 +1,215 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/n0ko/messages-tui/internal/client"
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// RetryMessageMsg is sent when the user retries a message that failed to send
+type RetryMessageMsg struct {
+	Message *store.Message
+}
+
+// DiscardMessageMsg is sent when the user drops a message that failed to send
+type DiscardMessageMsg struct {
+	Message *store.Message
+}
+
+// outgoingResultMsg reports whether the phone accepted an outbox message
+type outgoingResultMsg struct {
+	conversationID string
+	tmpID          string
+	attachment     bool
+	err            error
+}
+
+// queueMessage shows a message in the active conversation right away and
+// sends it in the background, as a reply to replyToID if set, from the SIM
+// simID or the conversation's if empty. While the phone is offline the
+// message waits in the outbox instead.
+func (a *App) queueMessage(content, replyToID, simID string) tea.Cmd {
+	// The input only needs to wait for the echo, not the phone
+	a.input, _ = a.input.Update(MessageSentNotifyMsg{})
+
+	if a.activeConversationID == "" {
+		log.Printf("App: sendMessage - no conversation selected")
+		a.statusMsg = "Select a conversation first! (Enter in contacts)"
+		return nil
+	}
+
+	return a.enqueue(&store.Message{
+		ID:             client.NewTmpID(),
+		ConversationID: a.activeConversationID,
+		SenderID:       "me",
+		Content:        content,
+		Timestamp:      time.Now(),
+		IsFromMe:       true,
+		ReplyToID:      replyToID,
+		SIMID:          simID,
+	})
+}
+
+// enqueue saves a message in the outbox and shows it in the active
+// conversation, sending it unless the phone is offline
+func (a *App) enqueue(msg *store.Message) tea.Cmd {
+	if err := a.store.QueueMessage(msg); err != nil {
+		// The message still goes out, it just won't survive a restart
+		log.Printf("App: failed to save outbox: %v", err)
+	}
+	a.contacts.SetConversations(a.store.GetConversations())
+
+	if a.phoneOffline(msg.ConversationID) {
+		a.messages.AddMessage(a.hold(msg))
+		a.statusMsg = "Phone offline, message queued until it's back"
+		return nil
+	}
+	a.messages.AddMessage(msg)
+
+	log.Printf("App: Sending message %s to conversation %s", msg.ID, msg.ConversationID)
+	return a.deliver(msg)
+}
+
+// deliver hands an outbox message to the phone, uploading its attachment
+// first if it has one
+func (a *App) deliver(msg *store.Message) tea.Cmd {
+	convID, tmpID, content, replyToID, simID := msg.ConversationID, msg.ID, msg.Content, msg.ReplyToID, msg.SIMID
+	if path := msg.Attachment; path != "" {
+		progress := func(p client.UploadProgress) {
+			// Drop updates rather than stall the upload if the UI is behind
+			select {
+			case a.externalMsgs <- UploadProgressMsg{Progress: p}:
+			default:
+			}
+		}
+		return func() tea.Msg {
+			err := a.client.SendMedia(a.ctx, convID, path, content, tmpID, simID, progress)
+			if err != nil {
+				log.Printf("App: SendMedia error: %v", err)
+			}
+			return outgoingResultMsg{conversationID: convID, tmpID: tmpID, attachment: true, err: err}
+		}
+	}
+	return func() tea.Msg {
+		err := a.client.SendMessage(a.ctx, convID, content, tmpID, replyToID, simID)
+		if err != nil {
+			log.Printf("App: SendMessage error: %v", err)
+		}
+		return outgoingResultMsg{conversationID: convID, tmpID: tmpID, err: err}
+	}
+}
+
+// hold marks an outbox message as waiting for the phone and returns the
+// updated copy
+func (a *App) hold(msg *store.Message) *store.Message {
+	updated, err := a.store.SetOutgoingStatus(msg.ConversationID, msg.ID, store.StatusQueued, "")
+	if err != nil {
+		log.Printf("App: failed to save outbox: %v", err)
+	}
+	if updated == nil {
+		return msg
+	}
+	return updated
+}
+
+// flushQueue sends the messages that waited for an account's phone, in the
+// order they were written
+func (a *App) flushQueue(account string) tea.Cmd {
+	queued := slices.DeleteFunc(a.store.QueuedMessages(), func(msg *store.Message) bool {
+		return a.conversationAccount(msg.ConversationID) != account
+	})
+	if len(queued) == 0 {
+		return nil
+	}
+
+	log.Printf("App: phone is back, sending %d queued messages", len(queued))
+	cmds := make([]tea.Cmd, 0, len(queued))
+	for _, msg := range queued {
+		updated, err := a.store.SetOutgoingStatus(msg.ConversationID, msg.ID, store.StatusPending, "")
+		if err != nil {
+			log.Printf("App: failed to save outbox: %v", err)
+		}
+		if updated == nil {
+			continue
+		}
+		a.messages.UpdateMessage(updated)
+		cmds = append(cmds, a.deliver(updated))
+	}
+	a.statusMsg = fmt.Sprintf("Phone is back, sending %d queued message(s)", len(cmds))
+	return tea.Sequence(cmds...)
+}
+
+// handleOutgoingResult marks an outbox message sent or failed. A message the
+// phone was unreachable for goes back in the queue instead of failing.
+func (a *App) handleOutgoingResult(msg outgoingResultMsg) {
+	if msg.attachment {
+		// Clear the upload indicator
+		a.input, _ = a.input.Update(MessageSentNotifyMsg{})
+	}
+
+	status, errText := store.StatusSent, ""
+	switch {
+	case msg.err == nil:
+	case errors.Is(msg.err, client.ErrPhoneOffline) || a.phoneOffline(msg.conversationID):
+		status = store.StatusQueued
+	default:
+		status, errText = store.StatusFailed, msg.err.Error()
+	}
+
+	updated, err := a.store.SetOutgoingStatus(msg.conversationID, msg.tmpID, status, errText)
+	if err != nil {
+		log.Printf("App: failed to save outbox: %v", err)
+	}
+	if updated != nil {
+		a.messages.UpdateMessage(updated)
+	}
+
+	switch {
+	case status == store.StatusQueued:
+		a.statusMsg = "Phone offline, message queued until it's back"
+	case msg.err != nil:
+		a.statusMsg = fmt.Sprintf("Message not sent: %v (R: retry, X: discard)", msg.err)
+	default:
+		a.statusMsg = "Message sent"
+	}
+}
+
+// retryMessage sends a failed message again under the same temporary ID, or
+// queues it if the phone is offline
+func (a *App) retryMessage(msg *store.Message) tea.Cmd {
+	if a.phoneOffline(msg.ConversationID) {
+		a.messages.UpdateMessage(a.hold(msg))
+		a.statusMsg = "Phone offline, message queued until it's back"
+		return nil
+	}
+	updated, err := a.store.SetOutgoingStatus(msg.ConversationID, msg.ID, store.StatusPending, "")
+	if err != nil {
+		log.Printf("App: failed to save outbox: %v", err)
+	}
+	if updated == nil {
+		return nil
+	}
+	a.messages.UpdateMessage(updated)
+	a.statusMsg = "Retrying..."
+	return a.deliver(updated)
+}
+
+// discardMessage drops a failed, queued or scheduled message from the thread
+// and the outbox or schedule
+func (a *App) discardMessage(msg *store.Message) {
+	if err := a.store.DiscardOutgoing(msg.ConversationID, msg.ID); err != nil {
+		log.Printf("App: failed to save outbox: %v", err)
+	}
+	a.messages.RemoveMessage(msg.ID)
+	a.statusMsg = "Message discarded"
+	if msg.Status == store.StatusScheduled {
+		a.statusMsg = "Scheduled message cancelled"
+	}
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/n0ko/messages-tui/internal/client"
+)
+
+// handlePhoneStatus shows what a phone said about itself, and sends the
+// messages queued for it once it answers again
+func (a *App) handlePhoneStatus(status client.PhoneStatus) tea.Cmd {
+	wasOffline := a.phones[status.Account].Offline
+	a.phones[status.Account] = status
+
+	phone := "Phone"
+	if status.Account != "" {
+		phone = "Phone of " + status.Account
+	}
+	switch {
+	case status.Offline && !wasOffline:
+		a.statusMsg = phone + " not responding, check it's on and online"
+	case !status.Offline && wasOffline:
+		a.statusMsg = phone + " is back"
+		return a.flushQueue(status.Account)
+	}
+	return nil
+}
+
+// phoneOffline reports whether the phone a conversation is on isn't responding
+func (a *App) phoneOffline(conversationID string) bool {
+	return a.phones[a.conversationAccount(conversationID)].Offline
+}
+
+// phoneIndicators renders a phone's network and battery for the status
+// bar, empty until the phone says
+func phoneIndicators(phone client.PhoneStatus) string {
+	var parts []string
+	switch phone.Network {
+	case client.NetworkWiFi:
+		parts = append(parts, "Wi-Fi")
+	case client.NetworkData:
+		parts = append(parts, "Mobile data")
+	}
+	if phone.BatteryLow {
+		parts = append(parts, "Battery low")
+	}
+	return strings.Join(parts, " · ")
+}
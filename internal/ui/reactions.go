@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// reactionChoices are the emoji offered by the reaction chooser, in the order
+// Google Messages shows them
+var reactionChoices = []string{"👍", "😍", "😂", "😮", "😥", "😠", "👎", "🤔", "❤️"}
+
+// SendReactionMsg is sent when the user picks a reaction for a message
+type SendReactionMsg struct {
+	Message *store.Message
+	Emoji   string
+}
+
+// openReactionPicker shows the reaction chooser for the selected message,
+// starting on the user's current reaction
+func (m *MessagesModel) openReactionPicker() {
+	sel := m.SelectedMessage()
+	if sel == nil {
+		return
+	}
+
+	m.picking = true
+	m.pickIndex = 0
+	mine := sel.MyReaction()
+	for i, emoji := range reactionChoices {
+		if emoji == mine {
+			m.pickIndex = i
+			break
+		}
+	}
+}
+
+// Picking returns whether the reaction chooser is open
+func (m MessagesModel) Picking() bool {
+	return m.picking
+}
+
+// handlePickerKey handles keys while the reaction chooser is open
+func (m MessagesModel) handlePickerKey(msg tea.KeyMsg) (MessagesModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+r":
+		m.picking = false
+		return m, nil
+
+	case "left", "h":
+		m.pickIndex = (m.pickIndex - 1 + len(reactionChoices)) % len(reactionChoices)
+		return m, nil
+
+	case "right", "l":
+		m.pickIndex = (m.pickIndex + 1) % len(reactionChoices)
+		return m, nil
+
+	case "enter":
+		return m.pickReaction(m.pickIndex)
+	}
+
+	// Number keys pick directly
+	if s := msg.String(); len(s) == 1 && s[0] >= '1' && s[0] <= '9' {
+		if i := int(s[0] - '1'); i < len(reactionChoices) {
+			return m.pickReaction(i)
+		}
+	}
+	return m, nil
+}
+
+// pickReaction closes the chooser and sends the chosen reaction
+func (m MessagesModel) pickReaction(i int) (MessagesModel, tea.Cmd) {
+	m.picking = false
+	sel := m.SelectedMessage()
+	if sel == nil {
+		return m, nil
+	}
+	emoji := reactionChoices[i]
+	return m, func() tea.Msg {
+		return SendReactionMsg{Message: sel, Emoji: emoji}
+	}
+}
+
+// pickerView renders the reaction chooser line
+func (m MessagesModel) pickerView() string {
+	mine := ""
+	if sel := m.SelectedMessage(); sel != nil {
+		mine = sel.MyReaction()
+	}
+
+	parts := make([]string, 0, len(reactionChoices))
+	for i, emoji := range reactionChoices {
+		label := fmt.Sprintf("%d %s", i+1, emoji)
+		switch {
+		case i == m.pickIndex:
+			label = m.styles.ReactionChoice.Render(label)
+		case emoji == mine:
+			label = m.styles.ReactionMine.Render(label)
+		}
+		parts = append(parts, label)
+	}
+	return m.styles.MessageSender.Render("React:") + " " + strings.Join(parts, " ")
+}
+
+// formatReactions renders a message's reactions with their counts and who
+// sent them, wrapped to width
+func (m MessagesModel) formatReactions(reactions []store.Reaction, width int) string {
+	var lines []string
+	line := ""
+	for _, r := range reactions {
+		who := append([]string(nil), r.Senders...)
+		if r.FromMe {
+			who = append(who, "you")
+		}
+
+		text := fmt.Sprintf("%s %d", r.Emoji, r.Count())
+		if len(who) > 0 {
+			text += " (" + strings.Join(who, ", ") + ")"
+		}
+		style := m.styles.MessageReaction
+		if r.FromMe {
+			style = m.styles.ReactionMine
+		}
+		chunk := style.Render(text)
+
+		if line != "" && lipgloss.Width(line)+2+lipgloss.Width(chunk) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += "  "
+		}
+		line += chunk
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
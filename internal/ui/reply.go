@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// ReplyMsg is sent when the user wants to reply to the selected message
+type ReplyMsg struct {
+	Message *store.Message
+}
+
+// BeginReply puts the input into reply mode for msg
+func (m *InputModel) BeginReply(msg *store.Message) {
+	m.replyTo = msg
+	m.mode = ModeInsert
+	m.textInput.Focus()
+}
+
+// CancelReply leaves reply mode
+func (m *InputModel) CancelReply() {
+	m.replyTo = nil
+}
+
+// ReplyingTo returns the message being replied to, or nil
+func (m InputModel) ReplyingTo() *store.Message {
+	return m.replyTo
+}
+
+// takeReply leaves reply mode and returns the ID of the message that was
+// being replied to, if any
+func (m *InputModel) takeReply() string {
+	if m.replyTo == nil {
+		return ""
+	}
+	id := m.replyTo.ID
+	m.replyTo = nil
+	return id
+}
+
+// Height returns the number of lines the input takes, borders included
+func (m InputModel) Height() int {
+	if m.replyTo != nil || m.rescheduling != nil {
+		return 4
+	}
+	return 3
+}
+
+// replyView renders the line above the input naming the message being
+// replied to
+func (m InputModel) replyView() string {
+	hint := m.styles.ContactPreview.Render(" (Esc twice cancels)")
+	label := "↪ Replying to " + quoteSender(m.replyTo) + ": "
+	snippet := messageSnippet(m.replyTo, m.width-4-len([]rune(label))-20)
+	return m.styles.MessageQuote.Render(label+snippet) + hint
+}
+
+// quoteView renders the quoted message a reply refers to
+func (m MessagesModel) quoteView(msg *store.Message, width int) string {
+	text := "Reply to an earlier message"
+	if quoted := m.findMessage(msg.ReplyToID); quoted != nil {
+		label := quoteSender(quoted) + ": "
+		text = label + messageSnippet(quoted, width-2-len([]rune(label)))
+	}
+	return m.styles.MessageQuote.Render("│ " + text)
+}
+
+// findMessage returns the loaded message with the given ID, or nil
+func (m MessagesModel) findMessage(id string) *store.Message {
+	for _, msg := range m.messages {
+		if msg.ID == id {
+			return msg
+		}
+	}
+	return nil
+}
+
+// quoteSender names the sender of a quoted message
+func quoteSender(msg *store.Message) string {
+	switch {
+	case msg.IsFromMe:
+		return "You"
+	case msg.SenderName != "":
+		return msg.SenderName
+	default:
+		return "Them"
+	}
+}
+
+// messageSnippet returns the first line of a message, cut to at most n runes
+func messageSnippet(msg *store.Message, n int) string {
+	text := msg.Content
+	if text == "" && msg.HasMedia() {
+		text = formatMediaPlaceholder(msg)
+	}
+	text, _, cut := strings.Cut(text, "\n")
+
+	runes := []rune(text)
+	n = max(n, 10)
+	if len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	if cut {
+		return text + " …"
+	}
+	return text
+}
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// ShowRosterMsg is sent when the user wants to see who is in a conversation
+type ShowRosterMsg struct {
+	ConversationID string
+}
+
+// SetConversation sets the conversation whose participants name the senders
+func (m *MessagesModel) SetConversation(conv *store.Conversation) {
+	m.conversation = conv
+}
+
+// startsRun returns whether the i-th message starts a run of messages from
+// the same sender, or is the first one on screen
+func (m MessagesModel) startsRun(i int) bool {
+	if i == 0 || i == m.offset {
+		return true
+	}
+	prev, msg := m.messages[i-1], m.messages[i]
+	return prev.IsFromMe != msg.IsFromMe || prev.SenderID != msg.SenderID
+}
+
+// senderName names the sender of a received message
+func (m MessagesModel) senderName(msg *store.Message) string {
+	if msg.SenderName != "" {
+		return msg.SenderName
+	}
+	if p := m.conversation.Participant(msg.SenderID); p != nil {
+		return p.DisplayName()
+	}
+	if m.conversation != nil && m.conversation.IsGroup {
+		return "Unknown"
+	}
+	return ""
+}
+
+// senderStyle returns the style of a sender's name, in their avatar color
+// in group conversations
+func (m MessagesModel) senderStyle(msg *store.Message) lipgloss.Style {
+	style := m.styles.MessageSender
+	if m.conversation == nil || !m.conversation.IsGroup {
+		return style
+	}
+	if p := m.conversation.Participant(msg.SenderID); p != nil && p.Color != "" {
+		style = style.Foreground(lipgloss.Color(p.Color))
+	}
+	return style
+}
+
+// handleRosterKey closes the roster
+func (a *App) handleRosterKey(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "esc", "q", "i", "enter":
+		a.roster = nil
+	}
+}
+
+// renderRoster renders the list of a conversation's participants
+func (a *App) renderRoster() string {
+	conv := a.roster
+	lines := []string{
+		a.styles.DialogTitle.Render(conv.Name),
+		a.styles.MessageStatus.Render(fmt.Sprintf("%d members", len(conv.Participants))),
+		"",
+	}
+
+	for _, p := range conv.Participants {
+		dot := a.styles.MessageStatus.Render("●")
+		if p.Color != "" {
+			dot = lipgloss.NewStyle().Foreground(lipgloss.Color(p.Color)).Render("●")
+		}
+		name := p.DisplayName()
+		if p.IsMe {
+			name = "You"
+		}
+		line := dot + " " + name
+		if p.FormattedNumber != "" && p.FormattedNumber != name {
+			line += "  " + a.styles.MessageStatus.Render(p.FormattedNumber)
+		}
+		lines = append(lines, line)
+	}
+	if len(conv.Participants) == 0 {
+		lines = append(lines, a.styles.MessageStatus.Render("The phone hasn't said who is in this conversation"))
+	}
+
+	lines = append(lines, "", a.styles.MessageStatus.Render("Esc: close"))
+	box := a.styles.Dialog.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+
+	return lipgloss.Place(
+		a.width, a.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
@@ -0,0 +1,488 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/n0ko/messages-tui/internal/client"
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// sendLaterInterval is how often scheduled messages are checked for being due
+const sendLaterInterval = 10 * time.Second
+
+// maxOverdueListed is how many overdue messages the startup question lists
+const maxOverdueListed = 5
+
+// ScheduleMessageMsg is sent when the user schedules a message to send later,
+// or edits one that is scheduled
+type ScheduleMessageMsg struct {
+	Content   string
+	At        time.Time
+	ReplyToID string // message being replied to, if any
+	SIMID     string // SIM picked for this message, empty for the conversation's
+	TmpID     string // scheduled message being edited, empty for a new one
+}
+
+// EditScheduledMsg is sent when the user wants to edit a scheduled message
+type EditScheduledMsg struct {
+	Message *store.Message
+}
+
+// sendLaterTickMsg triggers a check for scheduled messages that are due
+type sendLaterTickMsg struct{}
+
+// scheduleSendLaterCheck waits for the next due scheduled message check
+func scheduleSendLaterCheck() tea.Cmd {
+	return tea.Tick(sendLaterInterval, func(time.Time) tea.Msg {
+		return sendLaterTickMsg{}
+	})
+}
+
+// weekdays maps the names a day can be given by to the day
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// parseSendTime reads when to send a message: an offset from now like "+2h",
+// "+1h30m" or "+3d", or a time of day like "8", "8:00" or "6pm", optionally
+// after "today", "tomorrow", a weekday or a date like 2026-10-20. A time of
+// day on its own is the next time it comes round.
+func parseSendTime(expr string, now time.Time) (time.Time, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	if expr == "" {
+		return time.Time{}, errors.New(`say when, e.g. "tomorrow 8:00" or "+2h"`)
+	}
+
+	if offset, ok := strings.CutPrefix(expr, "+"); ok {
+		d, err := parseOffset(offset)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(d), nil
+	}
+
+	fields := strings.Fields(expr)
+	day, clock := "", fields[0]
+	switch len(fields) {
+	case 1:
+	case 2:
+		day, clock = fields[0], fields[1]
+	default:
+		return time.Time{}, fmt.Errorf("can't read %q as a time", expr)
+	}
+
+	hour, minute, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	at := func(date time.Time) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, now.Location())
+	}
+
+	var t time.Time
+	switch weekday, isWeekday := weekdays[day]; {
+	case day == "":
+		t = at(now)
+		if !t.After(now) {
+			t = at(now.AddDate(0, 0, 1))
+		}
+	case day == "today":
+		t = at(now)
+	case day == "tomorrow":
+		t = at(now.AddDate(0, 0, 1))
+	case isWeekday:
+		days := (int(weekday) - int(now.Weekday()) + 7) % 7
+		t = at(now.AddDate(0, 0, days))
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 7)
+		}
+	default:
+		date, err := time.ParseInLocation("2006-01-02", day, now.Location())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("can't read %q as a day, use today, tomorrow, a weekday or 2006-01-02", day)
+		}
+		t = at(date)
+	}
+
+	if !t.After(now) {
+		return time.Time{}, fmt.Errorf("%s has already passed", formatSendTime(t, now))
+	}
+	return t, nil
+}
+
+// parseOffset reads a duration like "2h", "1h30m" or "3d"
+func parseOffset(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("can't read %q as a delay, use e.g. +30m, +2h or +1d", "+"+s)
+	}
+	return d, nil
+}
+
+// parseClock reads a time of day like "8", "8:00", "18:30", "6pm" or "6:30pm"
+func parseClock(s string) (hour, minute int, err error) {
+	for _, layout := range []string{"15:04", "15", "3:04pm", "3pm"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Hour(), t.Minute(), nil
+		}
+	}
+	return 0, 0, fmt.Errorf("can't read %q as a time of day, use e.g. 8:00 or 6pm", s)
+}
+
+// formatSendTime describes when a message is scheduled for, relative to now
+func formatSendTime(t, now time.Time) string {
+	day := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	days := int(math.Round(day(t).Sub(day(now)).Hours() / 24))
+
+	clock := t.Format("15:04")
+	switch {
+	case days == 0:
+		return "today " + clock
+	case days == 1:
+		return "tomorrow " + clock
+	case days == -1:
+		return "yesterday " + clock
+	case days > 1 && days < 7:
+		return t.Format("Mon ") + clock
+	case t.Year() == now.Year():
+		return t.Format("Jan 2 ") + clock
+	default:
+		return t.Format("2006-01-02 ") + clock
+	}
+}
+
+// BeginSchedule switches the input to asking when to send the draft, keeping
+// the draft to send or restore afterwards. Does nothing without a draft.
+func (m *InputModel) BeginSchedule() {
+	if m.draftText() == "" {
+		return
+	}
+	m.savedDraft = m.draftContent
+	m.savedValue = m.textInput.Value()
+	m.draftContent = ""
+	m.textInput.Reset()
+
+	// Start from the current time when editing a scheduled message
+	if m.rescheduling != nil && m.rescheduling.Timestamp.After(time.Now()) {
+		m.textInput.SetValue(m.rescheduling.Timestamp.Format("2006-01-02 15:04"))
+	}
+
+	m.scheduling = true
+	m.scheduleHint = ""
+	m.mode = ModeInsert
+	m.textInput.Focus()
+}
+
+// endSchedule leaves the send later prompt and restores the saved draft
+func (m InputModel) endSchedule() InputModel {
+	m.scheduling = false
+	m.scheduleHint = ""
+	m.draftContent = m.savedDraft
+	m.textInput.SetValue(m.savedValue)
+	m.savedDraft = ""
+	m.savedValue = ""
+	return m
+}
+
+// Scheduling returns whether the input is asking when to send the draft
+func (m InputModel) Scheduling() bool {
+	return m.scheduling
+}
+
+// draftText returns the message being written, without surrounding space
+func (m InputModel) draftText() string {
+	if content := strings.TrimSpace(m.draftContent); content != "" {
+		return content
+	}
+	return strings.TrimSpace(m.textInput.Value())
+}
+
+// handleScheduleInput handles keys while typing when to send the draft
+func (m InputModel) handleScheduleInput(msg tea.KeyMsg) (InputModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEscape:
+		return m.endSchedule(), nil
+
+	case tea.KeyEnter:
+		at, err := parseSendTime(m.textInput.Value(), time.Now())
+		if err != nil {
+			m.scheduleHint = err.Error()
+			return m, nil
+		}
+		m = m.endSchedule()
+		return m.takeScheduled(at)
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	m.scheduleHint = ""
+	return m, cmd
+}
+
+// takeScheduled clears the draft and schedules it for at, or saves it to
+// the scheduled message being edited
+func (m InputModel) takeScheduled(at time.Time) (InputModel, tea.Cmd) {
+	schedule := ScheduleMessageMsg{Content: m.draftText(), At: at}
+	if m.rescheduling != nil {
+		schedule.TmpID = m.rescheduling.ID
+		m.rescheduling = nil
+	} else {
+		schedule.ReplyToID, schedule.SIMID = m.takeReply(), m.takeSIM()
+	}
+	m.textInput.Reset()
+	m.draftContent = ""
+	m.lastTyping = time.Time{}
+	return m, func() tea.Msg {
+		return schedule
+	}
+}
+
+// schedulePrompt returns the mode indicator and placeholder for the send
+// later prompt
+func (m InputModel) schedulePrompt() (indicator, placeholder string) {
+	return "[Later] ", "When to send, e.g. tomorrow 8:00 or +2h (Enter schedules, Esc cancels)"
+}
+
+// BeginReschedule puts a scheduled message in the input to edit it
+func (m *InputModel) BeginReschedule(msg *store.Message) {
+	m.rescheduling = msg
+	m.replyTo = nil
+	// Only a multiline message needs the editor to change
+	if strings.Contains(msg.Content, "\n") {
+		m.SetValue(msg.Content)
+	} else {
+		m.draftContent = ""
+		m.textInput.SetValue(msg.Content)
+		m.textInput.CursorEnd()
+	}
+	m.mode = ModeInsert
+	m.textInput.Focus()
+}
+
+// CancelReschedule stops editing a scheduled message, leaving it as it was
+func (m *InputModel) CancelReschedule() {
+	m.rescheduling = nil
+	m.draftContent = ""
+	m.textInput.Reset()
+}
+
+// Rescheduling returns the scheduled message being edited, or nil
+func (m InputModel) Rescheduling() *store.Message {
+	return m.rescheduling
+}
+
+// rescheduleView renders the line above the input naming the scheduled
+// message being edited
+func (m InputModel) rescheduleView() string {
+	hint := m.styles.ContactPreview.Render(" (Esc twice cancels)")
+	label := "⏰ Editing message for " + formatSendTime(m.rescheduling.Timestamp, time.Now())
+	return m.styles.MessageQuote.Render(label) + hint
+}
+
+// scheduleMessage puts a message in the schedule of the active conversation,
+// or saves the changes to a scheduled one
+func (a *App) scheduleMessage(msg ScheduleMessageMsg) {
+	if msg.TmpID != "" {
+		a.rescheduleMessage(msg)
+		return
+	}
+	if a.activeConversationID == "" {
+		a.statusMsg = "Select a conversation first! (Enter in contacts)"
+		return
+	}
+
+	scheduled := &store.Message{
+		ID:             client.NewTmpID(),
+		ConversationID: a.activeConversationID,
+		SenderID:       "me",
+		Content:        msg.Content,
+		Timestamp:      msg.At,
+		IsFromMe:       true,
+		ReplyToID:      msg.ReplyToID,
+		SIMID:          msg.SIMID,
+	}
+	if err := a.store.ScheduleMessage(scheduled); err != nil {
+		log.Printf("App: failed to save scheduled messages: %v", err)
+	}
+	a.messages.SetMessages(scheduled.ConversationID, a.store.GetMessages(scheduled.ConversationID))
+	a.statusMsg = "Message scheduled for " + formatSendTime(msg.At, time.Now())
+}
+
+// rescheduleMessage saves the new text and time of a scheduled message
+func (a *App) rescheduleMessage(msg ScheduleMessageMsg) {
+	conversationID := a.messages.conversationID
+	updated, err := a.store.Reschedule(conversationID, msg.TmpID, msg.Content, msg.At)
+	if err != nil {
+		log.Printf("App: failed to save scheduled messages: %v", err)
+	}
+	if updated == nil {
+		a.statusMsg = "The message was already sent"
+		return
+	}
+	// A message held back at startup goes out again if given a new time
+	if msg.At.After(time.Now()) {
+		delete(a.keptOverdue, msg.TmpID)
+	}
+	a.messages.SetMessages(conversationID, a.store.GetMessages(conversationID))
+	a.statusMsg = "Message rescheduled for " + formatSendTime(msg.At, time.Now())
+}
+
+// editScheduled opens a scheduled message in the input to change it
+func (a *App) editScheduled(msg *store.Message) {
+	a.focusPanel(PanelInput)
+	a.input.BeginReschedule(msg)
+	a.statusMsg = "Editing the scheduled message: Enter saves, Ctrl+T changes the time"
+}
+
+// sendDueMessages sends the scheduled messages whose time has come, unless
+// the user is still being asked about overdue ones
+func (a *App) sendDueMessages() tea.Cmd {
+	if a.state != StateConnected || a.overdue != nil {
+		return nil
+	}
+
+	var due []*store.Message
+	for _, msg := range a.store.DueMessages(time.Now()) {
+		if !a.keptOverdue[msg.ID] {
+			due = append(due, msg)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	log.Printf("App: sending %d scheduled messages", len(due))
+	a.statusMsg = fmt.Sprintf("Sending %d scheduled message(s)", len(due))
+	return a.releaseScheduled(due)
+}
+
+// releaseScheduled moves scheduled messages to the outbox and sends them, or
+// queues them while their phone is offline
+func (a *App) releaseScheduled(msgs []*store.Message) tea.Cmd {
+	cmds := make([]tea.Cmd, 0, len(msgs))
+	for _, msg := range msgs {
+		released, err := a.store.ReleaseScheduled(msg.ConversationID, msg.ID)
+		if err != nil {
+			log.Printf("App: failed to save scheduled messages: %v", err)
+		}
+		if released == nil {
+			continue
+		}
+		if a.phoneOffline(released.ConversationID) {
+			released = a.hold(released)
+		} else {
+			cmds = append(cmds, a.deliver(released))
+		}
+		// The outbox copy keeps the scheduled message's ID, so it takes its
+		// place in the panel
+		a.messages.UpdateMessage(released)
+	}
+	a.contacts.SetConversations(a.store.GetConversations())
+	return tea.Sequence(cmds...)
+}
+
+// askOverdue asks whether to send the scheduled messages whose time passed
+// while the app was closed
+func (a *App) askOverdue() {
+	var overdue []*store.Message
+	for _, msg := range a.store.DueMessages(time.Now()) {
+		if !a.keptOverdue[msg.ID] {
+			overdue = append(overdue, msg)
+		}
+	}
+	if len(overdue) > 0 {
+		a.overdue = overdue
+	}
+}
+
+// handleOverdueKey answers whether to send overdue scheduled messages. Ones
+// kept back stay in their conversations to be edited or cancelled.
+func (a *App) handleOverdueKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "y", "enter":
+		overdue := a.overdue
+		a.overdue = nil
+		a.statusMsg = fmt.Sprintf("Sending %d overdue message(s)", len(overdue))
+		return a.releaseScheduled(overdue)
+
+	case "n", "esc", "q":
+		for _, msg := range a.overdue {
+			a.keptOverdue[msg.ID] = true
+		}
+		a.statusMsg = fmt.Sprintf("Kept %d overdue message(s), e edits and X cancels them", len(a.overdue))
+		a.overdue = nil
+	}
+	return nil
+}
+
+// renderOverdueConfirm renders the question whether to send overdue
+// scheduled messages
+func (a *App) renderOverdueConfirm() string {
+	width := min(70, max(30, a.width-10))
+	now := time.Now()
+
+	lines := []string{
+		a.styles.DialogTitle.Render("Send overdue messages?"),
+		a.styles.MessageStatus.Render(fmt.Sprintf("%d scheduled message(s) came due while messages-tui was closed:", len(a.overdue))),
+		"",
+	}
+	for i, msg := range a.overdue {
+		if i == maxOverdueListed {
+			lines = append(lines, a.styles.MessageStatus.Render(fmt.Sprintf("and %d more", len(a.overdue)-i)))
+			break
+		}
+		label := fmt.Sprintf("%s, %s: ", a.conversationName(msg.ConversationID), formatSendTime(msg.Timestamp, now))
+		lines = append(lines, a.styles.MessageQuote.Render(label+messageSnippet(msg, width-len([]rune(label)))))
+	}
+	lines = append(lines,
+		"",
+		lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			a.styles.DialogButton.Render("y: send now"),
+			"  ",
+			a.styles.DialogButton.Render("n: keep to edit or cancel"),
+		),
+	)
+
+	box := a.styles.Dialog.Render(lipgloss.JoinVertical(lipgloss.Center, lines...))
+
+	return lipgloss.Place(
+		a.width, a.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
+
+// conversationName returns the name of a conversation, or its ID if it
+// isn't loaded
+func (a *App) conversationName(conversationID string) string {
+	if conv := a.store.GetConversation(conversationID); conv != nil && conv.Name != "" {
+		return conv.Name
+	}
+	return conversationID
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSendTime(t *testing.T) {
+	// A Friday afternoon
+	now := time.Date(2026, 10, 16, 14, 30, 0, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expr    string
+		want    time.Time
+		wantErr bool
+	}{
+		// Offsets
+		{expr: "+2h", want: now.Add(2 * time.Hour)},
+		{expr: "+1h30m", want: now.Add(90 * time.Minute)},
+		{expr: "+3d", want: now.Add(72 * time.Hour)},
+		{expr: "+0m", wantErr: true},
+		{expr: "+soon", wantErr: true},
+
+		// A time of day on its own is the next time it comes round
+		{expr: "18", want: at(10, 16, 18, 0)},
+		{expr: "6pm", want: at(10, 16, 18, 0)},
+		{expr: "8", want: at(10, 17, 8, 0)},
+		{expr: "8:00", want: at(10, 17, 8, 0)},
+		{expr: "14:30", want: at(10, 17, 14, 30)},
+
+		// Days
+		{expr: "today 18:30", want: at(10, 16, 18, 30)},
+		{expr: "today 8:00", wantErr: true},
+		{expr: "tomorrow 8", want: at(10, 17, 8, 0)},
+		{expr: "Tomorrow 6:30PM", want: at(10, 17, 18, 30)},
+		{expr: "fri 16:00", want: at(10, 16, 16, 0)},
+		{expr: "friday 9", want: at(10, 23, 9, 0)},
+		{expr: "mon 9", want: at(10, 19, 9, 0)},
+		{expr: "thursday 9", want: at(10, 22, 9, 0)},
+		{expr: "2026-10-20 9:15", want: at(10, 20, 9, 15)},
+		{expr: "2026-10-15 9", wantErr: true},
+
+		// Nonsense
+		{expr: "", wantErr: true},
+		{expr: "25:00", wantErr: true},
+		{expr: "someday 9", wantErr: true},
+		{expr: "next monday 9", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := parseSendTime(tt.expr, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// sessionStatus warns in the status bar that the session is about to
+// expire, empty if it isn't
+func (a *App) sessionStatus() string {
+	if a.sessionExpiry.IsZero() {
+		return ""
+	}
+	remaining := time.Until(a.sessionExpiry).Round(time.Minute)
+	if a.sessionAccount != "" {
+		if remaining <= 0 {
+			return fmt.Sprintf("Session of %s expired, run with -profile %s -clear-session to pair again", a.sessionAccount, a.sessionAccount)
+		}
+		return fmt.Sprintf("Session of %s expires in %s unless it can be refreshed, keep its phone online", a.sessionAccount, remaining)
+	}
+	if remaining <= 0 {
+		return "Session expired, run with -clear-session to pair again"
+	}
+	return fmt.Sprintf("Session expires in %s unless it can be refreshed, keep the phone online", remaining)
+}
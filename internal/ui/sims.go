@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/n0ko/messages-tui/internal/store"
+)
+
+// ChooseSIMMsg is sent when the user wants to pick the SIM a conversation
+// sends from
+type ChooseSIMMsg struct {
+	ConversationID string
+}
+
+// findSIM returns the SIM with the given participant ID, or nil. SIMs are
+// only worth showing when the phone has more than one, so it is also nil
+// for a single SIM.
+func findSIM(sims []store.SIM, id string) *store.SIM {
+	if len(sims) < 2 {
+		return nil
+	}
+	for i := range sims {
+		if sims[i].ParticipantID == id {
+			return &sims[i]
+		}
+	}
+	return nil
+}
+
+// simStyle returns style in the color of a SIM, if the phone gave it one
+func simStyle(style lipgloss.Style, sim *store.SIM) lipgloss.Style {
+	if sim != nil && sim.Color != "" {
+		return style.Foreground(lipgloss.Color(sim.Color))
+	}
+	return style
+}
+
+// SetSIMs sets the phone's SIMs to label conversations with
+func (m *ContactsModel) SetSIMs(sims []store.SIM) {
+	m.sims = sims
+}
+
+// SetSIMs sets the phone's SIMs to choose from
+func (m *InputModel) SetSIMs(sims []store.SIM) {
+	m.sims = sims
+}
+
+// SetConversationSIM sets the SIM the open conversation sends from
+func (m *InputModel) SetConversationSIM(simID string) {
+	m.sim = simID
+}
+
+// CancelSIMChoice goes back to the conversation's SIM for the next message
+func (m *InputModel) CancelSIMChoice() {
+	m.simChoice = ""
+}
+
+// cycleSIM picks the next SIM for the next message only, coming back round
+// to the conversation's SIM
+func (m *InputModel) cycleSIM() {
+	if len(m.sims) < 2 {
+		return
+	}
+	current := m.sim
+	if m.simChoice != "" {
+		current = m.simChoice
+	}
+	next := m.sims[0].ParticipantID
+	for i, sim := range m.sims {
+		if sim.ParticipantID == current {
+			next = m.sims[(i+1)%len(m.sims)].ParticipantID
+			break
+		}
+	}
+	m.simChoice = next
+	if next == m.sim {
+		m.simChoice = ""
+	}
+}
+
+// takeSIM returns the SIM picked for the next message, if any, and goes
+// back to the conversation's
+func (m *InputModel) takeSIM() string {
+	id := m.simChoice
+	m.simChoice = ""
+	return id
+}
+
+// simView renders which SIM the next message goes out on, if the phone has
+// more than one
+func (m InputModel) simView() string {
+	id := m.sim
+	if m.simChoice != "" {
+		id = m.simChoice
+	}
+	sim := findSIM(m.sims, id)
+	if sim == nil {
+		return ""
+	}
+	if m.simChoice != "" {
+		return simStyle(m.styles.ContactUnread, sim).Render(" via " + sim.Label() + " (this message)")
+	}
+	return simStyle(m.styles.ContactPreview, sim).Render(" via " + sim.Label())
+}
+
+// refreshSIMs shows a new list of the phone's SIMs
+func (a *App) refreshSIMs() {
+	a.contacts.SetSIMs(a.store.GetSIMs())
+	a.input.SetSIMs(a.accountSIMs(a.conversationAccount(a.activeConversationID)))
+}
+
+// chooseSIM opens the SIM chooser for a conversation
+func (a *App) chooseSIM(conversationID string) {
+	conv := a.store.GetConversation(conversationID)
+	if conv == nil {
+		return
+	}
+	if len(a.accountSIMs(conv.Account)) < 2 {
+		a.statusMsg = "The phone has only one SIM"
+		return
+	}
+	a.choosingSIM = conv
+}
+
+// handleSIMChoiceKey answers the SIM chooser
+func (a *App) handleSIMChoiceKey(msg tea.KeyMsg) {
+	conv := a.choosingSIM
+	sims := a.accountSIMs(conv.Account)
+
+	simID := ""
+	switch s := msg.String(); {
+	case s == "esc" || s == "q":
+		a.choosingSIM = nil
+		return
+	case s == "d":
+		// Back to the phone's default
+	case len(s) == 1 && s[0] >= '1' && int(s[0]-'1') < len(sims):
+		simID = sims[s[0]-'1'].ParticipantID
+	default:
+		return
+	}
+	a.choosingSIM = nil
+
+	if err := a.store.SetConversationSIM(conv.ID, simID); err != nil {
+		// The choice still holds until the app exits
+		log.Printf("App: failed to save conversation settings: %v", err)
+	}
+	a.contacts.SetConversations(a.store.GetConversations())
+
+	updated := a.store.GetConversation(conv.ID)
+	if updated == nil {
+		return
+	}
+	if conv.ID == a.activeConversationID {
+		a.input.SetConversationSIM(updated.SendingSIM())
+	}
+	a.statusMsg = conv.Name + " sends from the phone's default SIM"
+	if sim := findSIM(sims, updated.SendingSIM()); sim != nil {
+		a.statusMsg = fmt.Sprintf("%s sends from %s", conv.Name, sim.Label())
+	}
+}
+
+// renderSIMChooser renders the dialog for picking a conversation's SIM
+func (a *App) renderSIMChooser() string {
+	conv := a.choosingSIM
+	lines := []string{
+		a.styles.DialogTitle.Render("Send to " + conv.Name + " from"),
+		"",
+	}
+	for i, sim := range a.accountSIMs(conv.Account) {
+		line := fmt.Sprintf("%s  %s", a.styles.DialogButton.Render(fmt.Sprint(i+1)), simStyle(lipgloss.NewStyle(), &sim).Render(sim.Label()))
+		if sim.ParticipantID == conv.SendingSIM() {
+			line += a.styles.MessageStatus.Render("  (current)")
+		}
+		lines = append(lines, line)
+	}
+	defaultLine := fmt.Sprintf("%s  The phone's default", a.styles.DialogButton.Render("d"))
+	if conv.SIM == "" {
+		defaultLine += a.styles.MessageStatus.Render("  (current)")
+	}
+	lines = append(lines,
+		defaultLine,
+		"",
+		a.styles.MessageStatus.Render("Ctrl+S while typing switches SIM for one message · Esc: cancel"),
+	)
+
+	box := a.styles.Dialog.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+
+	return lipgloss.Place(
+		a.width, a.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
@@ -32,10 +32,11 @@ var (
 // Styles for different UI components
 type Styles struct {
 	// App-level styles
-	App             lipgloss.Style
-	StatusBar       lipgloss.Style
-	StatusBarLeader lipgloss.Style // Special style when leader key is active
-	HelpBar         lipgloss.Style
+	App              lipgloss.Style
+	StatusBar        lipgloss.Style
+	StatusBarLeader  lipgloss.Style // Special style when leader key is active
+	StatusBarOffline lipgloss.Style // While the phone or session needs attention
+	HelpBar          lipgloss.Style
 
 	// Panel styles
 	Panel          lipgloss.Style
@@ -50,14 +51,22 @@ type Styles struct {
 	ContactPreview      lipgloss.Style
 	ContactTime         lipgloss.Style
 	ContactUnread       lipgloss.Style
+	ContactMuted        lipgloss.Style
 
 	// Message styles
-	MessageSent       lipgloss.Style
-	MessageReceived   lipgloss.Style
-	MessageTime       lipgloss.Style
-	MessageSender     lipgloss.Style
-	MessageStatus     lipgloss.Style
-	MessageStatusRead lipgloss.Style
+	MessageSent         lipgloss.Style
+	MessageReceived     lipgloss.Style
+	MessageTime         lipgloss.Style
+	MessageSender       lipgloss.Style
+	MessageStatus       lipgloss.Style
+	MessageStatusRead   lipgloss.Style
+	MessageStatusFailed lipgloss.Style
+	MessageMedia        lipgloss.Style
+	MessageReaction     lipgloss.Style
+	MessageQuote        lipgloss.Style // Quoted message above a reply
+	ReactionMine        lipgloss.Style // Reactions the user sent
+	ReactionChoice      lipgloss.Style // Highlighted emoji in the reaction chooser
+	Typing              lipgloss.Style
 
 	// Input styles
 	Input         lipgloss.Style
@@ -95,6 +104,12 @@ func DefaultStyles() *Styles {
 		Bold(true).
 		Padding(0, 1)
 
+	s.StatusBarOffline = lipgloss.NewStyle().
+		Foreground(TextColor).
+		Background(TextErrorColor).
+		Bold(true).
+		Padding(0, 1)
+
 	s.HelpBar = lipgloss.NewStyle().
 		Foreground(TextMutedColor).
 		Background(SurfaceColor).
@@ -146,6 +161,10 @@ func DefaultStyles() *Styles {
 		Foreground(PrimaryColor).
 		Bold(true)
 
+	s.ContactMuted = lipgloss.NewStyle().
+		Foreground(TextMutedColor).
+		Faint(true)
+
 	// Message styles
 	s.MessageSent = lipgloss.NewStyle().
 		Background(SentMessageColor).
@@ -174,6 +193,33 @@ func DefaultStyles() *Styles {
 	s.MessageStatusRead = lipgloss.NewStyle().
 		Foreground(TextSuccessColor)
 
+	s.MessageStatusFailed = lipgloss.NewStyle().
+		Foreground(TextErrorColor).
+		Bold(true)
+
+	s.MessageMedia = lipgloss.NewStyle().
+		Foreground(CyanColor).
+		Italic(true)
+
+	s.MessageQuote = lipgloss.NewStyle().
+		Foreground(TextMutedColor).
+		Italic(true)
+
+	s.MessageReaction = lipgloss.NewStyle().
+		Foreground(TextMutedColor)
+
+	s.ReactionMine = lipgloss.NewStyle().
+		Foreground(SecondaryColor).
+		Bold(true)
+
+	s.ReactionChoice = lipgloss.NewStyle().
+		Background(PrimaryColor).
+		Bold(true)
+
+	s.Typing = lipgloss.NewStyle().
+		Foreground(SecondaryColor).
+		Italic(true)
+
 	// Input styles
 	s.Input = lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
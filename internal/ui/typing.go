@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/n0ko/messages-tui/internal/client"
+)
+
+// typingTimeout is how long someone is shown typing without a new update
+const typingTimeout = 15 * time.Second
+
+// typingInterval is the minimum time between our own typing notifications
+const typingInterval = 5 * time.Second
+
+// TypingMsg is sent by the input while the user types a message
+type TypingMsg struct{}
+
+// typingExpiredMsg prunes typing indicators that timed out
+type typingExpiredMsg struct{}
+
+// handleTypingEvent records that someone started or stopped typing
+func (a *App) handleTypingEvent(info *client.TypingInfo) tea.Cmd {
+	typers := a.typing[info.ConversationID]
+	if !info.Typing {
+		delete(typers, info.Name)
+		a.refreshTyping()
+		return nil
+	}
+
+	if typers == nil {
+		typers = make(map[string]time.Time)
+		a.typing[info.ConversationID] = typers
+	}
+	typers[info.Name] = time.Now().Add(typingTimeout)
+	a.refreshTyping()
+
+	return tea.Tick(typingTimeout, func(time.Time) tea.Msg {
+		return typingExpiredMsg{}
+	})
+}
+
+// clearTyping stops showing the sender of a message as typing
+func (a *App) clearTyping(conversationID, name string) {
+	typers := a.typing[conversationID]
+	if len(typers) == 0 {
+		return
+	}
+	// Senders of 1:1 threads may be named differently in messages and typing updates
+	if conv := a.store.GetConversation(conversationID); conv != nil && !conv.IsGroup {
+		delete(a.typing, conversationID)
+	} else {
+		delete(typers, name)
+	}
+	a.refreshTyping()
+}
+
+// refreshTyping drops expired typing indicators and updates the panels
+func (a *App) refreshTyping() {
+	now := time.Now()
+	labels := make(map[string]string, len(a.typing))
+	for convID, typers := range a.typing {
+		names := make([]string, 0, len(typers))
+		for name, until := range typers {
+			if now.After(until) {
+				delete(typers, name)
+				continue
+			}
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			delete(a.typing, convID)
+			continue
+		}
+		sort.Strings(names)
+		labels[convID] = typingLabel(names)
+	}
+
+	a.contacts.SetTyping(labels)
+	a.messages.SetTyping(labels[a.messages.conversationID])
+}
+
+// sendTyping notifies the active conversation that the user is typing
+func (a *App) sendTyping() tea.Cmd {
+	if a.activeConversationID == "" {
+		return nil
+	}
+	convID := a.activeConversationID
+	return func() tea.Msg {
+		// Typing notifications are best effort
+		if err := a.client.SetTyping(a.ctx, convID); err != nil {
+			log.Printf("App: SetTyping error: %v", err)
+		}
+		return nil
+	}
+}
+
+// typingLabel describes who is typing, e.g. "Alice is typing…"
+func typingLabel(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0] + " is typing…"
+	case 2:
+		return names[0] + " and " + names[1] + " are typing…"
+	default:
+		return fmt.Sprintf("%s and %d others are typing…", names[0], len(names)-1)
+	}
+}